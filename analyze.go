@@ -0,0 +1,78 @@
+package wappalyzer
+
+import (
+	"net/http"
+)
+
+// 页面数据，与采集方式无关，可由已有爬虫填充后调用 Analyze
+type PageData struct {
+	URL           string              // 页面完整 URL
+	Headers       map[string][]string // 响应头，与 http.Header 兼容
+	Cookies       map[string]string   // cookie 名 -> 值
+	HTML          string              // 页面 HTML，会从中提取 text、meta、script、dom
+	InlineScripts []string            // 内联 script 内容
+	ScriptSrcs    []string            // script src
+	Meta          map[string]string   // meta name/property/http-equiv -> content
+	CSS           []string            // 样式表内容
+	JSGlobals     map[string]string   // 已存在的 JavaScript 变量 -> 值，只知道变量存在时值为 ""，false、0、null 等假值视为不存在
+	DNS           map[string][]string // 记录类型 -> 记录值，如 MX、TXT
+	Robots        string              // robots.txt 内容
+	XHR           []string            // XHR 请求 URL
+	Certificate   *Certificate        // 主文档的 TLS 证书，certIssuer 规则匹配其 Issuer
+}
+
+// 分析页面数据，返回结果与 GetFingers 相同
+func (w *Wappalyzer) Analyze(page PageData) Result {
+	w.analyze(page)
+	return w.GetFingers()
+}
+
+func (w *Wappalyzer) analyze(page PageData) {
+	if page.URL != "" {
+		w.url(page.URL)
+	}
+	w.headers(page.Headers)
+	w.cookies(page.Cookies)
+	if page.HTML != "" {
+		w.analyzeHTML(page.HTML)
+	}
+	for _, script := range page.InlineScripts {
+		w.scriptContent(script, "")
+	}
+	w.scriptSrcs(page.ScriptSrcs)
+	for name, content := range page.Meta {
+		w.metaContent(name, content)
+	}
+	for _, css := range page.CSS {
+		w.css(css, page.URL)
+	}
+	w.jsGlobals(page.JSGlobals)
+	w.dnsRecords(page.DNS)
+	if page.Robots != "" {
+		w.robots(page.Robots, "")
+	}
+	for _, xhr := range page.XHR {
+		w.xhr(xhr)
+	}
+	if page.Certificate != nil {
+		w.certIssuer(page.Certificate)
+	}
+}
+
+// 将 HTTP 响应转换为 PageData
+func responsePage(res *http.Response, body []byte) PageData {
+	page := PageData{Headers: res.Header, Cookies: make(map[string]string)}
+	if res.Request != nil && res.Request.URL != nil {
+		page.URL = res.Request.URL.String()
+	}
+	for _, cookie := range res.Cookies() {
+		page.Cookies[cookie.Name] = cookie.Value
+	}
+	if res.TLS != nil {
+		page.Certificate = leafCertificate(*res.TLS)
+	}
+	if isHTML(res.Header.Get("Content-Type"), body) {
+		page.HTML = string(body)
+	}
+	return page
+}
@@ -0,0 +1,193 @@
+package wappalyzer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// 以 name:confidence 形式输出结果，便于比较
+func resultConfidences(result Result) string {
+	res := make([]string, 0, len(result.Technologies))
+	for _, tech := range result.Technologies {
+		res = append(res, fmt.Sprintf("%s:%d", tech.Name, tech.Confidence))
+	}
+	return strings.Join(res, ",")
+}
+
+func TestAnalyze(t *testing.T) {
+	tests := []struct {
+		name         string
+		technologies string
+		page         PageData
+		want         string
+	}{
+		{
+			name: "url and headers",
+			technologies: `{
+				"Nginx": {"cats": [1], "headers": {"Server": "nginx"}},
+				"Admin": {"cats": [1], "url": "/wp-admin/"}
+			}`,
+			page: PageData{URL: "https://example.com/wp-admin/", Headers: map[string][]string{"Server": {"nginx/1.20.1"}}},
+			want: "Admin:100,Nginx:100",
+		},
+		{
+			name: "cookies",
+			technologies: `{
+				"PHP": {"cats": [3], "cookies": {"PHPSESSID": ""}},
+				"Laravel": {"cats": [1], "cookies": {"laravel_session": ""}}
+			}`,
+			page: PageData{Cookies: map[string]string{"PHPSESSID": "abc"}},
+			want: "PHP:100",
+		},
+		{
+			name: "html extracts meta script and text",
+			technologies: `{
+				"WordPress": {"cats": [1], "meta": {"generator": "WordPress"}},
+				"jQuery": {"cats": [1], "scriptSrc": "jquery\\.js"},
+				"Inline": {"cats": [1], "scripts": "inline-marker"},
+				"Text": {"cats": [1], "text": "Powered by Text"}
+			}`,
+			page: PageData{HTML: `<html><head>
+				<meta name="generator" content="WordPress">
+				<script src="/jquery.js"></script>
+				<script>var inline = "inline-marker";</script>
+			</head><body><p>Powered by Text</p></body></html>`},
+			want: "Inline:100,Text:100,WordPress:100,jQuery:100",
+		},
+		{
+			name: "requires satisfied by implied",
+			technologies: `{
+				"WordPress": {"cats": [1], "meta": {"generator": "WordPress"}, "implies": "PHP"},
+				"PHP": {"cats": [3]},
+				"Plugin": {"cats": [1], "scriptSrc": "plugin\\.js", "requires": "PHP"}
+			}`,
+			page: PageData{Meta: map[string]string{"generator": "WordPress"}, ScriptSrcs: []string{"/plugin.js"}},
+			want: "PHP:100,Plugin:100,WordPress:100",
+		},
+		{
+			name: "css js dns robots and xhr",
+			technologies: `{
+				"Bootstrap": {"cats": [1], "css": "\\.navbar-bootstrap"},
+				"Vue": {"cats": [1], "js": {"Vue": ""}},
+				"Google Workspace": {"cats": [1], "dns": {"MX": "google\\.com"}},
+				"Shopify": {"cats": [2], "robots": "Disallow: /cart"},
+				"Algolia": {"cats": [1], "xhr": "algolia\\.net"}
+			}`,
+			page: PageData{
+				CSS:       []string{".navbar-bootstrap{color:red}"},
+				JSGlobals: map[string]string{"Vue": "3.2.0"},
+				DNS:       map[string][]string{"MX": {"aspmx.l.google.com"}},
+				Robots:    "User-agent: *\nDisallow: /cart",
+				XHR:       []string{"https://x.algolia.net/1/indexes"},
+			},
+			want: "Algolia:100,Bootstrap:100,Google Workspace:100,Shopify:100,Vue:100",
+		},
+		{
+			name: "case-insensitive header names",
+			technologies: `{
+				"Nginx": {"cats": [1], "headers": {"server": "nginx"}},
+				"Express": {"cats": [1], "headers": {"X-Powered-By": "^Express$"}}
+			}`,
+			page: PageData{Headers: map[string][]string{"SERVER": {"nginx/1.20.1"}, "x-powered-by": {"Express"}}},
+			want: "Express:100,Nginx:100",
+		},
+		{
+			name: "multi-value headers",
+			technologies: `{
+				"Shopify": {"cats": [2], "headers": {"Set-Cookie": "^_shopify_y="}},
+				"Preload": {"cats": [1], "headers": {"Link": "rel=preload$"}}
+			}`,
+			page: PageData{Headers: map[string][]string{
+				"Set-Cookie": {"session=1; Path=/", "_shopify_y=2; Path=/"},
+				"Link":       {"</a.css>; rel=preload", "</b.js>; rel=modulepreload"},
+			}},
+			want: "Preload:100,Shopify:100",
+		},
+		{
+			name: "empty header pattern means exists",
+			technologies: `{
+				"Varnish": {"cats": [1], "headers": {"Via": "varnish"}},
+				"Cache": {"cats": [1], "headers": {"X-Cache": ""}}
+			}`,
+			page: PageData{Headers: map[string][]string{"X-Cache": {""}}},
+			want: "Cache:100",
+		},
+		{
+			name: "falsy js globals are ignored",
+			technologies: `{
+				"jQuery": {"cats": [1], "js": {"jQuery.fn.jquery": "([\\d.]+)\\;version:\\1"}},
+				"Angular": {"cats": [1], "js": {"angular": ""}},
+				"Ember": {"cats": [1], "js": {"Ember": ""}},
+				"Vue": {"cats": [1], "js": {"Vue": ""}}
+			}`,
+			page: PageData{JSGlobals: map[string]string{"jQuery.fn.jquery": "0", "angular": "false", "Ember": "null", "Vue": ""}},
+			want: "Vue:100",
+		},
+		{
+			name: "empty js global means exists",
+			technologies: `{
+				"Vue": {"cats": [1], "js": {"Vue": ""}},
+				"React": {"cats": [1], "js": {"React.version": "([\\d.]+)\\;version:\\1"}}
+			}`,
+			page: PageData{JSGlobals: map[string]string{"Vue": "", "React.version": ""}},
+			want: "Vue:100",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := NewWappalyzer(loadTestDB(t, test.technologies))
+			if got := resultConfidences(w.Analyze(test.page)); got != test.want {
+				t.Errorf("result = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestGetFingersRepeated(t *testing.T) {
+	db := loadTestDB(t, `{
+		"Nginx": {"cats": [1], "icon": "Nginx.svg", "headers": {"Server": "nginx(?:/([\\d.]+))?\\;version:\\1"}, "implies": "Lua"},
+		"Lua": {"cats": [3], "icon": "Lua.svg"}
+	}`)
+	SetReadICONURL("/geticon?icon=")
+	defer SetReadICONURL("")
+	w := NewWappalyzer(db)
+	first := w.Analyze(PageData{Headers: map[string][]string{"Server": {"nginx/1.20.1"}}})
+
+	var wg sync.WaitGroup
+	results := make([]Result, 8)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// 与检测并发执行
+			w.headers(map[string][]string{"Server": {"nginx"}})
+			results[i] = w.GetFingers()
+		}()
+	}
+	wg.Wait()
+
+	for _, result := range append(results, first) {
+		if got := resultConfidences(result); got != "Lua:100,Nginx:100" {
+			t.Fatalf("result = %q", got)
+		}
+		nginx, _ := result.Get("Nginx")
+		if nginx.Icon != "/geticon?icon=Nginx.svg" || nginx.Version != "1.20.1" {
+			t.Errorf("Nginx icon = %q, version = %q", nginx.Icon, nginx.Version)
+		}
+	}
+	detected := w.Detected()
+	names := make([]string, 0, len(detected))
+	for name, tech := range detected {
+		names = append(names, name)
+		if tech.Icon != "Nginx.svg" {
+			t.Errorf("raw icon = %q, want unprefixed", tech.Icon)
+		}
+	}
+	sort.Strings(names)
+	if strings.Join(names, ",") != "Nginx" {
+		t.Errorf("detected = %v, want only Nginx", names)
+	}
+}
@@ -0,0 +1,104 @@
+package wappalyzer
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// 连接 host 读取叶子证书，host 未指定端口时使用 443
+func (w *Wappalyzer) DetectCert(ctx context.Context, host string) error {
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		host = net.JoinHostPort(host, "443")
+	}
+	server_name, _, _ := net.SplitHostPort(host)
+	dialer := tls.Dialer{
+		NetDialer: &net.Dialer{Timeout: 10 * time.Second},
+		Config: &tls.Config{
+			ServerName:         server_name,
+			InsecureSkipVerify: true,
+		},
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if cert := leafCertificate(conn.(*tls.Conn).ConnectionState()); cert != nil {
+		w.certIssuer(cert)
+	}
+	return nil
+}
+
+func leafCertificate(state tls.ConnectionState) *Certificate {
+	if len(state.PeerCertificates) == 0 {
+		return nil
+	}
+	return newCertificate(state.PeerCertificates[0])
+}
+
+// 两种模式的 Subject 均为 CN，Issuer 均为 O 与 CN 以 ", " 连接，如 "Let's Encrypt, R3"
+func newCertificate(cert *x509.Certificate) *Certificate {
+	san := make([]string, 0, len(cert.DNSNames)+len(cert.IPAddresses))
+	san = append(san, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		san = append(san, ip.String())
+	}
+	return &Certificate{
+		Subject: cert.Subject.CommonName,
+		SAN:     san,
+		Issuer:  issuerName(cert.Issuer),
+	}
+}
+
+func issuerName(name pkix.Name) string {
+	parts := slices.Clone(name.Organization)
+	if name.CommonName != "" && !slices.Contains(parts, name.CommonName) {
+		parts = append(parts, name.CommonName)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// 通过 Network.getCertificate 读取主文档证书，与 tls.Dial 得到的格式一致
+// 读取失败时退回 SecurityDetails，此时 Issuer 只有 CN
+func responseCertificate(ctx context.Context, res *network.Response) *Certificate {
+	details := res.SecurityDetails
+	fallback := &Certificate{Subject: details.SubjectName, SAN: details.SanList, Issuer: details.Issuer}
+	parse, err := url.Parse(res.URL)
+	if err != nil {
+		return fallback
+	}
+	origin := parse.Scheme + "://" + parse.Host
+	tables, err := network.GetCertificate(origin).Do(cdp.WithExecutor(ctx, chromedp.FromContext(ctx).Target))
+	if err != nil || len(tables) == 0 {
+		return fallback
+	}
+	der, err := base64.StdEncoding.DecodeString(tables[0])
+	if err != nil {
+		return fallback
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return fallback
+	}
+	return newCertificate(cert)
+}
+
+func (w *Wappalyzer) certIssuer(cert *Certificate) {
+	if cert.Issuer == "" {
+		return
+	}
+	for _, t := range w.db.index[sourceCert] {
+		w.runPatterns(t.certIssuer, cert.Issuer, t, Evidence{Source: sourceCert, Location: cert.Subject, Certificate: cert})
+	}
+}
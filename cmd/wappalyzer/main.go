@@ -15,21 +15,28 @@ import (
 	"time"
 )
 
-//go:embed wappalyzer
+// all: 前缀才会包含 _.json
+//
+//go:embed all:wappalyzer/src
 var wappalyzer_fs embed.FS
 
-// 列wappalyzer_fs目录，没找到_.json
-//go:embed wappalyzer/src/technologies/_.json
-var file_ string
-
 func main() {
-	err := wappalyzer.InitWappalyzerDB(wappalyzer_fs, file_)
+	db, err := wappalyzer.LoadFS(wappalyzer_fs)
 	if err != nil {
 		fmt.Println(err)
 		return
 	}
 	wappalyzer.SetReadICONURL("/geticon?icon=")
 
+	if len(os.Args) < 2 {
+		fmt.Println("usage: wappalyzer <url> | wappalyzer har <file.har>")
+		return
+	}
+	if os.Args[1] == "har" && len(os.Args) == 3 {
+		detectHAR(db, os.Args[2])
+		return
+	}
+
 	opts := append(chromedp.DefaultExecAllocatorOptions[:],
 		chromedp.Flag("incognito", true),
 		chromedp.Flag("ignore-certificate-errors", true),
@@ -47,31 +54,35 @@ func main() {
 	ctx, cancel = chromedp.NewContext(ctx)
 	defer cancel()
 
-	newWappalyzer := wappalyzer.NewWappalyzer(false)
+	newWappalyzer := wappalyzer.NewWappalyzer(db)
 
 	parse, err := url.Parse(os.Args[1])
 	if err != nil {
 		fmt.Println(err)
 		return
 	}
-	if strings.Contains(parse.Host, ":") {
-		parse.Host = strings.Split(parse.Host, ":")[0]
+	if err = newWappalyzer.DetectDNS(ctx, parse.Hostname()); err != nil {
+		fmt.Println(err)
+	}
+	if err = newWappalyzer.DetectRobots(ctx, os.Args[1], nil); err != nil {
+		fmt.Println(err)
 	}
-	newWappalyzer.DetectDNS(parse.Host)
-	newWappalyzer.DetectRobots(os.Args[1])
 
 	chromedp.ListenTarget(ctx, newWappalyzer.DetectListen(ctx))
 	if err = chromedp.Run(ctx, task(os.Args[1], newWappalyzer.DetectActions())); err != nil {
 		fmt.Println(err)
 		return
 	}
+	if err = newWappalyzer.Wait(ctx); err != nil {
+		fmt.Println(err)
+	}
 
 	marshal, _ := json.MarshalIndent(newWappalyzer.GetFingers(), "", "  ")
 	fmt.Println(string(marshal))
 
 	// 测试获取产品图标
 	engine := gin.Default()
-	engine.GET("/geticon", getICON)
+	engine.GET("/geticon", getICON(db))
 	err = engine.Run(":9990")
 	if err != nil {
 		fmt.Println(err)
@@ -79,13 +90,32 @@ func main() {
 	}
 }
 
-func getICON(c *gin.Context) {
-	icon := c.Query("icon")
-	readICON := wappalyzer.ReadICON(icon)
-	if strings.HasSuffix(icon, "svg") {
-		c.Header("Content-Type", "image/svg+xml")
+// 离线分析 HAR 文件
+func detectHAR(db *wappalyzer.DB, filename string) {
+	file, err := os.Open(filename)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer file.Close()
+	fingers, err := wappalyzer.NewWappalyzer(db).DetectHAR(file)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	marshal, _ := json.MarshalIndent(fingers, "", "  ")
+	fmt.Println(string(marshal))
+}
+
+func getICON(db *wappalyzer.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		icon := c.Query("icon")
+		readICON := db.ReadICON(icon)
+		if strings.HasSuffix(icon, "svg") {
+			c.Header("Content-Type", "image/svg+xml")
+		}
+		c.String(200, readICON)
 	}
-	c.String(200, readICON)
 }
 
 func task(urlstr string, wapp chromedp.Tasks) chromedp.Tasks {
@@ -0,0 +1,448 @@
+package wappalyzer
+
+import (
+	"fmt"
+	"github.com/andybalholm/cascadia"
+	"github.com/dlclark/regexp2"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 回退引擎单次匹配的最长耗时
+const fallbackMatchTimeout = 100 * time.Millisecond
+
+// 指纹来源
+const (
+	sourceHeaders   = "headers"
+	sourceCookies   = "cookies"
+	sourceDNS       = "dns"
+	sourceJS        = "js"
+	sourceMeta      = "meta"
+	sourceDOM       = "dom"
+	sourceHTML      = "html"
+	sourceText      = "text"
+	sourceCSS       = "css"
+	sourceRobots    = "robots"
+	sourceURL       = "url"
+	sourceXHR       = "xhr"
+	sourceScriptSrc = "scriptSrc"
+	sourceScripts   = "scripts"
+	sourceCert      = "certIssuer"
+	sourceImplied   = "implied"
+)
+
+// Evidence.Match 的最大长度
+const evidenceMatchLimit = 100
+
+// 加载时编译失败的规则
+type PatternError struct {
+	Technology string
+	Source     string
+	Pattern    string
+	Err        error
+}
+
+func (e *PatternError) Error() string {
+	return fmt.Sprintf("%s %s %q: %s", e.Technology, e.Source, e.Pattern, e.Err)
+}
+
+func (e *PatternError) Unwrap() error {
+	return e.Err
+}
+
+// 正则引擎
+type matcher interface {
+	FindStringSubmatch(s string) []string
+}
+
+// golang 不支持的正则(零宽断言、反向引用等)使用 regexp2 匹配，按 JavaScript 语义编译
+type fallbackMatcher struct {
+	regex *regexp2.Regexp
+}
+
+func (m *fallbackMatcher) FindStringSubmatch(s string) []string {
+	match, err := m.regex.FindStringMatch(s)
+	if err != nil {
+		// 超过 fallbackMatchTimeout 时视为未命中
+		stdLogger().Debug("fallback regexp", "pattern", m.regex.String(), "length", len(s), "error", err)
+		return nil
+	}
+	if match == nil {
+		return nil
+	}
+	groups := match.Groups()
+	matchs := make([]string, len(groups))
+	for i := range groups {
+		matchs[i] = groups[i].String()
+	}
+	return matchs
+}
+
+// 预编译后的规则，如 nginx(?:/([\d.]+))?\;version:\1\;confidence:50
+type pattern struct {
+	raw        string  // 原始规则
+	regex      matcher // \; 之前的正则
+	fallback   bool    // 是否使用 regexp2
+	version    string  // 版本模板
+	confidence int
+}
+
+func parsePattern(raw string) (*pattern, error) {
+	attrs := strings.Split(raw, "\\;")
+	p := &pattern{raw: raw, confidence: 100}
+	for _, attr := range attrs[1:] {
+		key, value, ok := strings.Cut(attr, ":")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "version":
+			p.version = value
+		case "confidence":
+			confidence, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, err
+			}
+			p.confidence = confidence
+		}
+	}
+	// 与 wappalyzer 一致，规则均忽略大小写
+	regex, err := regexp.Compile("(?i)" + attrs[0])
+	if err == nil {
+		p.regex = regex
+		return p, nil
+	}
+	fallback, err_ := regexp2.Compile(attrs[0], regexp2.ECMAScript|regexp2.IgnoreCase)
+	if err_ != nil {
+		return nil, err
+	}
+	fallback.MatchTimeout = fallbackMatchTimeout
+	p.regex = &fallbackMatcher{regex: fallback}
+	p.fallback = true
+	return p, nil
+}
+
+// matched 为整个正则匹配到的内容
+func (p *pattern) match(data string) (exist bool, version string, confidence int, matched string) {
+	matchs := p.regex.FindStringSubmatch(data)
+	if len(matchs) == 0 {
+		return false, "", p.confidence, ""
+	}
+	return true, resolveVersion(p.version, matchs), p.confidence, matchs[0]
+}
+
+// 解析版本模板，与 wappalyzer 的 resolveVersion 一致
+// 支持 \N 反向引用、\1.\2 拼接以及 \1?a:b 三元表达式(分组非空取 a，否则取 b)
+func resolveVersion(template string, matchs []string) string {
+	if template == "" {
+		return ""
+	}
+	resolved := template
+	for index, match := range matchs {
+		ref := "\\" + strconv.Itoa(index)
+		if i := strings.Index(resolved, ref+"?"); i != -1 {
+			rest := resolved[i+len(ref)+1:]
+			if j := strings.Index(rest, ":"); j > 0 {
+				if match != "" {
+					resolved = resolved[:i] + rest[:j]
+				} else {
+					resolved = resolved[:i] + rest[j+1:]
+				}
+			}
+		}
+		resolved = strings.ReplaceAll(strings.TrimSpace(resolved), ref, match)
+	}
+	return resolved
+}
+
+// DOM 规则，selector 命中后依次检查 exists、text、properties、attributes
+type domRule struct {
+	selector   string
+	sel        cascadia.Sel // HTTP 模式使用，解析失败时为 nil
+	exists     bool
+	text       []*pattern
+	properties map[string]*pattern // 规则为 nil 时只要求属性存在
+	attributes map[string]*pattern
+}
+
+// implies 中的指纹，如 PHP\;confidence:50
+type implied struct {
+	raw        string
+	name       string
+	confidence int
+	version    string
+}
+
+func parseImplied(raw string) *implied {
+	attrs := strings.Split(raw, "\\;")
+	i := &implied{raw: raw, name: strings.TrimSpace(attrs[0]), confidence: 100}
+	for _, attr := range attrs[1:] {
+		key, value, ok := strings.Cut(attr, ":")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "version":
+			i.version = value
+		case "confidence":
+			if confidence, err := strconv.Atoi(value); err == nil {
+				i.confidence = confidence
+			}
+		}
+	}
+	return i
+}
+
+// 预编译后的指纹
+type technology struct {
+	name       string
+	props      Properties
+	categories []Categorie
+	headers    map[string][]*pattern
+	cookies    map[string][]*pattern
+	dns        map[string][]*pattern
+	js         map[string][]*pattern
+	meta       map[string][]*pattern
+	dom        []*domRule
+	html       []*pattern
+	text       []*pattern
+	css        []*pattern
+	robots     []*pattern
+	url        []*pattern
+	xhr        []*pattern
+	scriptSrc  []*pattern
+	scripts    []*pattern
+	certIssuer []*pattern
+
+	requires         []string // 依赖的指纹，需至少检测到其中一个
+	requiresCategory []int    // 依赖的分类，需至少检测到其中一个
+	implies          []*implied
+	excludes         []string
+}
+
+func (t *technology) newTechnologie(confidence int, version string) Technologie {
+	return Technologie{
+		Name:       t.name,
+		Confidence: confidence,
+		Version:    version,
+		Icon:       t.props.ICON,
+		Website:    t.props.WebSite,
+		Cpe:        t.props.CPE,
+		Categories: t.categories,
+	}
+}
+
+// 加载时编译全部指纹，按来源建立索引，检测时只遍历对应来源的指纹
+func (d *DB) compile() {
+	d.technologies = make(map[string]*technology)
+	d.index = make(map[string][]*technology)
+	d.invalid = make([]*PatternError, 0)
+	d.fallback = make([]string, 0)
+	names := make([]string, 0, len(d.schemas))
+	for name := range d.schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		props := d.schemas[name]
+		t := &technology{name: name, props: props, categories: make([]Categorie, 0)}
+		for _, cat := range props.Cats {
+			t.categories = append(t.categories, Categorie{
+				ID:   cat,
+				Name: d.categories[strconv.Itoa(cat)].Name,
+			})
+		}
+		t.headers = make(map[string][]*pattern)
+		for key, patterns := range d.compileMap(name, sourceHeaders, props.Headers) {
+			key = http.CanonicalHeaderKey(key)
+			t.headers[key] = append(t.headers[key], patterns...)
+		}
+		t.cookies = d.compileMap(name, sourceCookies, props.Cookie)
+		t.dns = d.compileMap(name, sourceDNS, props.DNS)
+		t.js = d.compileMap(name, sourceJS, props.JS)
+		t.meta = make(map[string][]*pattern)
+		for key, patterns := range d.compileMap(name, sourceMeta, props.Meta) {
+			key = strings.ToLower(key)
+			t.meta[key] = append(t.meta[key], patterns...)
+		}
+		t.dom = d.compileDOM(name, props.DOM)
+		t.html = d.compilePatterns(name, sourceHTML, props.HTML)
+		t.text = d.compilePatterns(name, sourceText, props.TEXT)
+		t.css = d.compilePatterns(name, sourceCSS, props.CSS)
+		t.robots = d.compilePatterns(name, sourceRobots, props.Robots)
+		t.url = d.compilePatterns(name, sourceURL, props.URL)
+		t.xhr = d.compilePatterns(name, sourceXHR, props.XHR)
+		t.scriptSrc = d.compilePatterns(name, sourceScriptSrc, props.ScriptSrc)
+		t.scripts = d.compilePatterns(name, sourceScripts, props.Scripts)
+		t.certIssuer = d.compilePatterns(name, sourceCert, props.CertIssuer)
+		t.requires = toStrings(props.Requires)
+		t.excludes = toStrings(props.Excludes)
+		for _, raw := range toStrings(props.Implies) {
+			t.implies = append(t.implies, parseImplied(raw))
+		}
+		switch cats := TypeDetect(props.RequiresCategory).(type) {
+		case float64:
+			t.requiresCategory = []int{int(cats)}
+		case []float64:
+			for _, cat := range cats {
+				t.requiresCategory = append(t.requiresCategory, int(cat))
+			}
+		}
+		d.technologies[name] = t
+
+		sources := map[string]bool{
+			sourceHeaders:   len(t.headers) != 0,
+			sourceCookies:   len(t.cookies) != 0,
+			sourceDNS:       len(t.dns) != 0,
+			sourceJS:        len(t.js) != 0,
+			sourceMeta:      len(t.meta) != 0,
+			sourceDOM:       len(t.dom) != 0,
+			sourceHTML:      len(t.html) != 0,
+			sourceText:      len(t.text) != 0,
+			sourceCSS:       len(t.css) != 0,
+			sourceRobots:    len(t.robots) != 0,
+			sourceURL:       len(t.url) != 0,
+			sourceXHR:       len(t.xhr) != 0,
+			sourceScriptSrc: len(t.scriptSrc) != 0,
+			sourceScripts:   len(t.scripts) != 0,
+			sourceCert:      len(t.certIssuer) != 0,
+		}
+		for source, ok := range sources {
+			if ok {
+				d.index[source] = append(d.index[source], t)
+			}
+		}
+	}
+}
+
+// 编译失败时记录错误并返回 nil
+func (d *DB) compilePattern(name, source, raw string) *pattern {
+	p, err := parsePattern(raw)
+	if err != nil {
+		d.invalid = append(d.invalid, &PatternError{Technology: name, Source: source, Pattern: raw, Err: err})
+		return nil
+	}
+	if p.fallback && (len(d.fallback) == 0 || d.fallback[len(d.fallback)-1] != name) {
+		d.fallback = append(d.fallback, name)
+	}
+	return p
+}
+
+// string 或 []string
+func (d *DB) compilePatterns(name, source string, raw interface{}) []*pattern {
+	patterns := make([]*pattern, 0)
+	for _, r := range toStrings(raw) {
+		if p := d.compilePattern(name, source, r); p != nil {
+			patterns = append(patterns, p)
+		}
+	}
+	return patterns
+}
+
+// map[string]string 或 map[string][]string
+func (d *DB) compileMap(name, source string, raw interface{}) map[string][]*pattern {
+	patterns := make(map[string][]*pattern)
+	if m, ok := raw.(map[string]string); ok {
+		for key, r := range m {
+			if p := d.compilePattern(name, source, r); p != nil {
+				patterns[key] = append(patterns[key], p)
+			}
+		}
+		return patterns
+	}
+	if m, ok := raw.(map[string]interface{}); ok && len(m) != 0 {
+		switch v := TypeDetect(m).(type) {
+		case map[string]string:
+			return d.compileMap(name, source, v)
+		case map[string][]string:
+			for key, raws := range v {
+				for _, r := range raws {
+					if p := d.compilePattern(name, source, r); p != nil {
+						patterns[key] = append(patterns[key], p)
+					}
+				}
+			}
+		}
+	}
+	return patterns
+}
+
+// DOM - 可能为DOMStr、DOMObj、DOMStr|DOMObj Arr
+func (d *DB) compileDOM(name string, raw interface{}) []*domRule {
+	rules := make([]*domRule, 0)
+	newRule := func(selector string) *domRule {
+		rule := &domRule{selector: selector, properties: make(map[string]*pattern), attributes: make(map[string]*pattern)}
+		rule.sel, _ = cascadia.Parse(selector)
+		rules = append(rules, rule)
+		return rule
+	}
+	switch doms := TypeDetect(raw).(type) {
+	case string, []string:
+		for _, selector := range split(doms, ",") {
+			newRule(selector).exists = true
+		}
+	case map[string]map[string]string:
+		for keys, vals := range doms {
+			for _, selector := range split(keys, ",") {
+				rule := newRule(selector)
+				for key, val := range vals {
+					switch key {
+					case "exists":
+						rule.exists = true
+					case "text":
+						if p := d.compilePattern(name, sourceDOM, val); p != nil {
+							rule.text = append(rule.text, p)
+						}
+					case "properties":
+						rule.properties[val] = nil
+					}
+				}
+			}
+		}
+	case map[string]map[string]map[string]string:
+		for keys, vals := range doms {
+			for _, selector := range split(keys, ",") {
+				rule := newRule(selector)
+				for key, val := range vals {
+					switch key {
+					case "exists":
+						rule.exists = true
+					case "text":
+						for _, r := range val {
+							if p := d.compilePattern(name, sourceDOM, r); p != nil {
+								rule.text = append(rule.text, p)
+							}
+						}
+					case "properties":
+						for property, r := range val {
+							if p := d.compilePattern(name, sourceDOM, r); p != nil {
+								rule.properties[property] = p
+							}
+						}
+					case "attributes":
+						for attribute, r := range val {
+							if p := d.compilePattern(name, sourceDOM, r); p != nil {
+								rule.attributes[attribute] = p
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	return rules
+}
+
+// 加载时编译失败的规则
+func (d *DB) InvalidPatterns() []*PatternError {
+	return d.invalid
+}
+
+// 使用 regexp2 回退引擎的指纹名称，按名称排序
+func (d *DB) FallbackTechnologies() []string {
+	return d.fallback
+}
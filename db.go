@@ -0,0 +1,291 @@
+package wappalyzer
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+// 指纹库，加载后只读，可同时存在多份并由多个 Wappalyzer 共享
+type DB struct {
+	schemas    Schema
+	groups     Groups
+	categories Categories
+	fs         fs.FS // technologies 的上级目录，用于读取ICON，单文件加载时为空
+
+	technologies map[string]*technology   // 预编译后的指纹
+	index        map[string][]*technology // 来源 -> 包含该来源规则的指纹
+	invalid      []*PatternError
+	fallback     []string // 使用 regexp2 的指纹
+}
+
+// 加载指纹库 - 第一个指纹wr不包含
+// Deprecated: 使用 //go:embed all:wappalyzer/src 后调用 LoadFS
+func InitWappalyzerDB(wr embed.FS, file_ string) (*DB, error) {
+	wr_sub, err := fs.Sub(wr, "wappalyzer")
+	if err != nil {
+		return nil, err
+	}
+	return loadFS(wr_sub, []byte(file_))
+}
+
+// 从 fs.FS 加载指纹库
+// 自动查找 technologies 目录下的所有 *.json，categories.json、groups.json 位于 technologies 的上级目录
+func LoadFS(fsys fs.FS) (*DB, error) {
+	return loadFS(fsys)
+}
+
+// 从磁盘目录加载指纹库，如 git clone 下来的 wappalyzer 目录
+func LoadDir(dir string) (*DB, error) {
+	return loadFS(os.DirFS(dir))
+}
+
+// 从合并后的单个 JSON 文件加载指纹库，格式见 LoadReader
+func LoadFile(filename string) (*DB, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return LoadReader(file)
+}
+
+// 从合并后的 JSON 加载指纹库
+// 格式为 {"technologies": {...}, "categories": {...}, "groups": {...}}，technologies 也可写作 apps
+// 不包含以上字段时，整个 JSON 视为 technologies
+func LoadReader(r io.Reader) (*DB, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var merged struct {
+		Technologies Schema     `json:"technologies"`
+		Apps         Schema     `json:"apps"`
+		Categories   Categories `json:"categories"`
+		Groups       Groups     `json:"groups"`
+	}
+	err = json.Unmarshal(content, &merged)
+	if err != nil {
+		return nil, err
+	}
+	d := &DB{schemas: make(Schema), groups: merged.Groups, categories: merged.Categories}
+	switch {
+	case merged.Technologies != nil || merged.Apps != nil:
+		for k, v := range merged.Apps {
+			d.schemas[k] = v
+		}
+		for k, v := range merged.Technologies {
+			d.schemas[k] = v
+		}
+	default:
+		err = json.Unmarshal(content, &d.schemas)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if d.groups == nil {
+		d.groups = make(Groups)
+	}
+	if d.categories == nil {
+		d.categories = make(Categories)
+	}
+	return d, d.validate()
+}
+
+// extra 为额外的 technologies 文件内容
+func loadFS(fsys fs.FS, extra ...[]byte) (*DB, error) {
+	technologies, err := findTechnologies(fsys)
+	if err != nil {
+		return nil, err
+	}
+	root := path.Dir(technologies)
+	files, err := fs.Glob(fsys, path.Join(technologies, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	d := &DB{schemas: make(Schema), groups: make(Groups), categories: make(Categories)}
+	for _, file := range files {
+		file_content, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+		extra = append(extra, file_content)
+	}
+	for _, file_content := range extra {
+		file_content = bytes.TrimSpace(file_content)
+		if len(file_content) == 0 {
+			continue
+		}
+		var schema Schema
+		err = json.Unmarshal(file_content, &schema)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range schema {
+			d.schemas[k] = v
+		}
+	}
+	err = readJSON(fsys, path.Join(root, "groups.json"), &d.groups)
+	if err != nil {
+		return nil, err
+	}
+	err = readJSON(fsys, path.Join(root, "categories.json"), &d.categories)
+	if err != nil {
+		return nil, err
+	}
+	d.fs, err = fs.Sub(fsys, root)
+	if err != nil {
+		return nil, err
+	}
+	return d, d.validate()
+}
+
+// 查找层级最浅的 technologies 目录
+func findTechnologies(fsys fs.FS) (string, error) {
+	dirs := make([]string, 0)
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		switch d.Name() {
+		case ".git", "node_modules":
+			return fs.SkipDir
+		case "technologies":
+			dirs = append(dirs, name)
+			return fs.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(dirs) == 0 {
+		return "", errors.New("technologies directory not found")
+	}
+	sort.SliceStable(dirs, func(i, j int) bool {
+		return strings.Count(dirs[i], "/") < strings.Count(dirs[j], "/")
+	})
+	return dirs[0], nil
+}
+
+// 文件不存在时忽略
+func readJSON(fsys fs.FS, name string, v interface{}) error {
+	content, err := fs.ReadFile(fsys, name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(content, v)
+}
+
+func (d *DB) validate() error {
+	icon_null_count := 0
+	var err error
+	for name, val := range d.schemas {
+		// 测试是否有未知类型
+		_, err = TypeTest(val.Implies)
+		if err != nil {
+			return fmt.Errorf("%s %s", name, err)
+		}
+		_, err = TypeTest(val.Requires)
+		if err != nil {
+			return fmt.Errorf("%s %s", name, err)
+		}
+		_, err = TypeTest(val.RequiresCategory)
+		if err != nil {
+			return fmt.Errorf("%s %s", name, err)
+		}
+		_, err = TypeTest(val.Excludes)
+		if err != nil {
+			return fmt.Errorf("%s %s", name, err)
+		}
+		_, err = TypeTest(val.DOM)
+		if err != nil {
+			return fmt.Errorf("%s %s", name, err)
+		}
+		_, err = TypeTest(val.DNS)
+		if err != nil {
+			return fmt.Errorf("%s %s", name, err)
+		}
+		_, err = TypeTest(val.HTML)
+		if err != nil {
+			return fmt.Errorf("%s %s", name, err)
+		}
+		_, err = TypeTest(val.TEXT)
+		if err != nil {
+			return fmt.Errorf("%s %s", name, err)
+		}
+		_, err = TypeTest(val.CSS)
+		if err != nil {
+			return fmt.Errorf("%s %s", name, err)
+		}
+		_, err = TypeTest(val.Robots)
+		if err != nil {
+			return fmt.Errorf("%s %s", name, err)
+		}
+		_, err = TypeTest(val.URL)
+		if err != nil {
+			return fmt.Errorf("%s %s", name, err)
+		}
+		_, err = TypeTest(val.XHR)
+		if err != nil {
+			return fmt.Errorf("%s %s", name, err)
+		}
+		_, err = TypeTest(val.Meta)
+		if err != nil {
+			return fmt.Errorf("%s %s", name, err)
+		}
+		_, err = TypeTest(val.ScriptSrc)
+		if err != nil {
+			return fmt.Errorf("%s %s", name, err)
+		}
+		_, err = TypeTest(val.CertIssuer)
+		if err != nil {
+			return fmt.Errorf("%s %s", name, err)
+		}
+		// 测试ICON是否读取正常
+		if val.ICON != "" && !strings.Contains(val.ICON, "<") {
+			if d.fs != nil && d.ReadICON(val.ICON) == "" {
+				stdLogger().Debug("icon not found", "technology", name, "icon", val.ICON)
+			}
+			continue
+		}
+		icon_null_count++
+	}
+	d.compile()
+	for _, e := range d.invalid {
+		stdLogger().Warn("invalid pattern", "technology", e.Technology, "source", e.Source, "pattern", e.Pattern, "error", e.Err)
+	}
+	if len(d.fallback) != 0 {
+		stdLogger().Debug("fallback regexp", "technologies", strings.Join(d.fallback, ", "))
+	}
+	stdLogger().Info("wappalyzer db loaded", "fingers", len(d.schemas), "groups", len(d.groups), "categories", len(d.categories), "no_icon", icon_null_count, "invalid_pattern", len(d.invalid))
+	return nil
+}
+
+func (d *DB) ReadICON(filename string) string {
+	if d.fs == nil {
+		return ""
+	}
+	icon, err := fs.ReadFile(d.fs, "images/icons/"+filename)
+	if err != nil {
+		icon, err = fs.ReadFile(d.fs, "drivers/webextension/images/icons/"+filename)
+		if err != nil {
+			return ""
+		}
+	}
+	return string(icon)
+}
@@ -2,67 +2,103 @@ package wappalyzer
 
 import (
 	"context"
-	"crypto/tls"
+	"encoding/json"
 	"fmt"
 	"github.com/chromedp/cdproto/cdp"
 	"github.com/chromedp/cdproto/dom"
 	"github.com/chromedp/cdproto/network"
 	"github.com/chromedp/cdproto/runtime"
 	"github.com/chromedp/chromedp"
-	"github.com/miekg/dns"
-	"io"
-	"log"
-	"math/rand"
 	"net/http"
-	"os"
 	"strings"
-	"time"
 )
 
-func init() {
-	rand.Seed(time.Now().UnixNano())
-}
-
 func (w *Wappalyzer) DetectListen(ctx context.Context) func(ev interface{}) {
 	return func(ev interface{}) {
 		switch e := ev.(type) {
 		case *network.EventWebSocketCreated:
-			go w.websocket(e.URL)
+			w.listen(func() {
+				w.websocket(e.URL)
+			})
 		case *network.EventRequestWillBeSent:
-			go func() {
+			w.listen(func() {
 				if e.Type == "XHR" {
 					w.xhr(e.Request.URL)
 				} else if e.Type == "Document" {
 					w.url(e.Request.URL)
 				}
-			}()
+			})
 		case *network.EventResponseReceived:
-			go func() {
-				headers := make(map[string]string)
+			w.listen(func() {
+				// chrome 使用 \n 连接同名响应头
+				headers := make(map[string][]string)
 				for key, inf := range e.Response.Headers {
 					switch val := inf.(type) {
 					case string:
-						headers[key] = val
+						headers[key] = append(headers[key], strings.Split(val, "\n")...)
 						break
 					case []string:
-						headers[key] = strings.Join(val, "; ")
+						headers[key] = append(headers[key], val...)
 						break
 					}
 				}
 				w.headers(headers)
+				if e.Type == network.ResourceTypeDocument && e.Response.SecurityDetails != nil {
+					w.certIssuer(responseCertificate(ctx, e.Response))
+				}
 				body, err := network.GetResponseBody(e.RequestID).Do(cdp.WithExecutor(ctx, chromedp.FromContext(ctx).Target))
 				if err != nil {
-					w.PrintError(err)
+					w.log().Debug("get response body", "url", e.Response.URL, "error", err)
 					return
 				}
-				if e.Type == "Stylesheet" {
-					w.css(string(body))
+				switch e.Type {
+				case "Stylesheet":
+					w.css(string(body), e.Response.URL)
+				case "Script":
+					w.scriptContent(string(body), e.Response.URL)
 				}
-			}()
+			})
 		}
 	}
 }
 
+// 在后台执行监听到的任务，同时运行的任务数不超过 listenWorkers，Wait 等待全部完成
+// Wait 开始后收到的事件直接丢弃，避免 pending.Add 与 pending.Wait 并发
+func (w *Wappalyzer) listen(task func()) {
+	w.lock.Lock()
+	if w.closed {
+		w.lock.Unlock()
+		return
+	}
+	w.pending.Add(1)
+	w.lock.Unlock()
+	go func() {
+		defer w.pending.Done()
+		w.workers <- struct{}{}
+		defer func() { <-w.workers }()
+		task()
+	}()
+}
+
+// 等待 DetectListen 已接收事件的处理完成，应在页面加载结束、GetFingers 之前调用
+// 调用后不再处理新的监听事件
+func (w *Wappalyzer) Wait(ctx context.Context) error {
+	w.lock.Lock()
+	w.closed = true
+	w.lock.Unlock()
+	done := make(chan struct{})
+	go func() {
+		w.pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 func (w *Wappalyzer) DetectActions() chromedp.Tasks {
 	return chromedp.Tasks{
 		w.cookie(),
@@ -71,244 +107,93 @@ func (w *Wappalyzer) DetectActions() chromedp.Tasks {
 		w.meta(),
 		w.scripts(),
 		w.scriptsrc(),
+		w.bodyText(),
+		w.styles(),
 	}
 }
 
-// 已测试
-func (w *Wappalyzer) DetectDNS(domain string) {
-	recoards := make(map[string][]string)
-	var dnserver = []string{"114.114.114.114"}
-	c := dns.Client{Timeout: 10 * time.Second}
-	m := dns.Msg{}
-	m.SetQuestion(domain+".", dns.TypeANY)
-	r, _, err := c.Exchange(&m, fmt.Sprintf("%s:53", dnserver[rand.Intn(len(dnserver))]))
-	if err != nil {
-		w.PrintError("dns error", err)
-		return
-	}
-	
-	recoards["MX"] = make([]string, 0)
-	recoards["TXT"] = make([]string, 0)
-	recoards["SOA"] = make([]string, 0)
-	recoards["NS"] = make([]string, 0)
-	for _, ans := range r.Answer {
-		switch a := ans.(type) {
-		case *dns.MX:
-			recoards["MX"] = append(recoards["MX"], a.Mx)
-			break
-		case *dns.TXT:
-			recoards["TXT"] = append(recoards["TXT"], a.Txt...)
-			break
-		case *dns.SOA:
-			recoards["SOA"] = append(recoards["SOA"], a.Ns)
-			break
-		case *dns.NS:
-			recoards["NS"] = append(recoards["NS"], a.Ns, a.Ns)
-			break
-		}
-	}
-	for name, value := range schemas {
-		if value.DNS == nil {
-			continue
-		}
-		switch dns_ := TypeDetect(value.DNS).(type) {
-		case map[string]string:
-			for recoard, regstr := range dns_ {
-				for i := 0; i < len(recoards[recoard]); i++ {
-					exist, version, confidence := w.regexp(regstr, recoards[recoard][i])
-					if exist {
-						w.setFinger(name, value, confidence, version)
-					}
-				}
-			}
-			break
-		case map[string][]string:
-			for recoard, regstrs := range dns_ {
-				for _, regstr := range regstrs {
-					for i := 0; i < len(recoards[recoard]); i++ {
-						exist, version, confidence := w.regexp(regstr, recoards[recoard][i])
-						if exist {
-							w.setFinger(name, value, confidence, version)
-						}
-					}
-				}
+// recoards 为记录类型 -> 记录值
+func (w *Wappalyzer) dnsRecords(recoards map[string][]string) {
+	for _, t := range w.db.index[sourceDNS] {
+		for recoard, patterns := range t.dns {
+			for i := 0; i < len(recoards[recoard]); i++ {
+				w.runPatterns(patterns, recoards[recoard][i], t, Evidence{Source: sourceDNS, Location: recoard})
 			}
-			break
-		default:
-			w.PrintError("found no dns type", value.DNS)
 		}
 	}
 }
 
-// 已测试
-func (w *Wappalyzer) DetectRobots(req_url string) {
-	cli := http.Client{
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: true,
-			},
-		},
-		Timeout: 10 * time.Second,
-	}
-	req, err := http.NewRequest(http.MethodGet, strings.Trim(req_url, "/")+"/robots.txt", nil)
-	if err != nil {
-		w.PrintError(err)
-		return
-	}
-	res, err := cli.Do(req)
-	if err != nil {
-		w.PrintError(err)
-		return
-	}
-	defer res.Body.Close()
-	body, err := io.ReadAll(res.Body)
-	if err != nil {
-		w.PrintError(err)
-		return
-	}
-	for name, value := range schemas {
-		if value.Robots == nil {
-			continue
-		}
-		switch robots := TypeDetect(value.Robots).(type) {
-		case string:
-			w.runRegexp(robots, string(body), name, value)
-			break
-		case []string:
-			for _, r := range robots {
-				w.runRegexp(r, string(body), name, value)
-			}
-			break
-		default:
-			w.PrintError("found no url type", value.XHR)
-		}
+func (w *Wappalyzer) robots(body string, location string) {
+	for _, t := range w.db.index[sourceRobots] {
+		w.runPatterns(t.robots, body, t, Evidence{Source: sourceRobots, Location: location})
 	}
 }
 
 // 已测试-
-func (w *Wappalyzer) headers(headers map[string]string) {
-	for name, value := range schemas {
-		if value.Headers == nil {
-			continue
-		}
-		for key, val := range headers {
-			if _, ok := value.Headers[key]; !ok {
+// 响应头名称两侧均规范化后比较，同名响应头(如 Set-Cookie、Link)的每个值分别匹配
+// 规则为空时只要求响应头存在
+func (w *Wappalyzer) headers(headers map[string][]string) {
+	for _, t := range w.db.index[sourceHeaders] {
+		for key, values := range headers {
+			key = http.CanonicalHeaderKey(key)
+			patterns, ok := t.headers[key]
+			if !ok {
 				continue
 			}
-			w.runRegexp(value.Headers[key], val, name, value)
+			for _, value := range values {
+				w.runPatterns(patterns, value, t, Evidence{Source: sourceHeaders, Location: key})
+			}
 		}
 	}
 }
 
 // 已测试
 func (w *Wappalyzer) text(text string) {
-	for name, value := range schemas {
-		if value.TEXT == nil {
-			continue
-		}
-		switch texts := TypeDetect(value.TEXT).(type) {
-		case string:
-			w.runMatch(texts, text, name, value)
-			break
-		case []string:
-			for _, t := range texts {
-				w.runMatch(t, text, name, value)
-			}
-			break
-		default:
-			w.PrintError("found no text type", value.XHR)
-		}
+	for _, t := range w.db.index[sourceText] {
+		w.runPatterns(t.text, text, t, Evidence{Source: sourceText})
 	}
 }
 
+// 单个 CSS 来源参与匹配的最大长度
+const cssSourceLimit = 1 << 20
+
 // 已测试
-func (w *Wappalyzer) css(body string) {
-	for name, value := range schemas {
-		if value.CSS == nil {
-			continue
-		}
-		switch css := TypeDetect(value.CSS).(type) {
-		case string:
-			w.runRegexp(css, body, name, value)
-			break
-		case []string:
-			for _, c := range css {
-				w.runRegexp(c, body, name, value)
-			}
-			break
-		default:
-			w.PrintError("found no css type", value.XHR)
-		}
+func (w *Wappalyzer) css(body string, location string) {
+	if len(body) > cssSourceLimit {
+		body = body[:cssSourceLimit]
+	}
+	for _, t := range w.db.index[sourceCSS] {
+		w.runPatterns(t.css, body, t, Evidence{Source: sourceCSS, Location: location})
 	}
 }
 
 // 已测试
 func (w *Wappalyzer) url(full_url string) {
-	for name, value := range schemas {
-		if value.URL == nil {
-			continue
-		}
-		switch url := TypeDetect(value.URL).(type) {
-		case string:
-			w.runRegexp(url, full_url, name, value)
-			break
-		case []string:
-			for _, u := range url {
-				w.runRegexp(u, full_url, name, value)
-			}
-			break
-		default:
-			w.PrintError("found no url type", value.XHR)
-		}
+	for _, t := range w.db.index[sourceURL] {
+		w.runPatterns(t.url, full_url, t, Evidence{Source: sourceURL, Location: full_url})
 	}
 }
 
 // 已测试
 func (w *Wappalyzer) xhr(xhr_url string) {
-	for name, value := range schemas {
-		if value.XHR == nil {
-			continue
-		}
-		switch xhr := TypeDetect(value.XHR).(type) {
-		case string:
-			w.runRegexp(xhr, xhr_url, name, value)
-			break
-		case []string:
-			for _, x := range xhr {
-				w.runRegexp(x, xhr_url, name, value)
-			}
-			break
-		default:
-			w.PrintError("found no xhr type", value.XHR)
-		}
+	for _, t := range w.db.index[sourceXHR] {
+		w.runPatterns(t.xhr, xhr_url, t, Evidence{Source: sourceXHR, Location: xhr_url})
 	}
 }
 
 // 已测试
 func (w *Wappalyzer) websocket(websocket_url string) {
 	if strings.HasPrefix(websocket_url, "ws://") || strings.HasPrefix(websocket_url, "wss://") {
-		w.setFinger("Websocket", schemas["Websocket"], 100, "")
+		if t, ok := w.db.technologies["Websocket"]; ok {
+			w.setFinger(t, "websocket", 100, "", Evidence{Source: "websocket", Match: truncate(websocket_url), Location: websocket_url})
+		}
 	}
 }
 
 // 已测试 -> DOM
 func (w *Wappalyzer) html(body string) {
-	for name, value := range schemas {
-		if value.HTML == nil {
-			continue
-		}
-		switch v := TypeDetect(value.HTML).(type) {
-		case string:
-			w.runRegexp(v, body, name, value)
-			break
-		case []string:
-			for i := 0; i < len(v); i++ {
-				w.runRegexp(v[i], body, name, value)
-			}
-			break
-		default:
-			w.PrintError("HTML found no type", value.HTML)
-		}
+	for _, t := range w.db.index[sourceHTML] {
+		w.runPatterns(t.html, body, t, Evidence{Source: sourceHTML})
 	}
 }
 
@@ -319,24 +204,23 @@ func (w *Wappalyzer) cookie() chromedp.Action {
 		if err != nil {
 			return err
 		}
+		values := make(map[string]string)
 		for i := 0; i < len(cookies); i++ {
-			for name, value := range schemas {
-				if value.Cookie == nil {
-					continue
-				}
-				if _, ok := value.Cookie[cookies[i].Name]; !ok {
-					continue
-				}
-				exist, version, confidence := w.regexp(value.Cookie[cookies[i].Name], cookies[i].Value)
-				if exist {
-					w.setFinger(name, value, confidence, version)
-				}
-			}
+			values[cookies[i].Name] = cookies[i].Value
 		}
+		w.cookies(values)
 		return nil
 	})
 }
 
+func (w *Wappalyzer) cookies(cookies map[string]string) {
+	for _, t := range w.db.index[sourceCookies] {
+		for name, value := range cookies {
+			w.runPatterns(t.cookies[name], value, t, Evidence{Source: sourceCookies, Location: name})
+		}
+	}
+}
+
 // 已测试
 func (w *Wappalyzer) dom() chromedp.Action {
 	return chromedp.ActionFunc(func(ctx context.Context) error {
@@ -349,185 +233,154 @@ func (w *Wappalyzer) dom() chromedp.Action {
 			return err
 		}
 		w.html(html)
-		for name, value := range schemas {
-			if value.DOM == nil {
-				continue
-			}
-			switch doms := TypeDetect(value.DOM).(type) {
-			case string, []string:
-				all_doms := w.split(doms, ",")
-				for i := 0; i < len(all_doms); i++ {
-					node_res, err := dom.QuerySelector(node.NodeID, all_doms[i]).Do(ctx)
+		for _, t := range w.db.index[sourceDOM] {
+			for _, rule := range t.dom {
+				node_ress, err := dom.QuerySelectorAll(node.NodeID, rule.selector).Do(ctx)
+				if err != nil {
+					w.log().Warn("query selector", "technology", t.name, "selector", rule.selector, "error", err)
+					continue
+				}
+				if len(node_ress) == 0 {
+					continue
+				}
+				if rule.exists {
+					w.setFinger(t, rule, 100, "", Evidence{Source: sourceDOM, Location: rule.selector})
+				}
+				for property, p := range rule.properties {
+					selector, _ := json.Marshal(rule.selector)
+					name, _ := json.Marshal(property)
+					res, exception, err := runtime.Evaluate(fmt.Sprintf(domPropertyScript, selector, name)).WithReturnByValue(true).Do(ctx)
 					if err != nil {
+						w.log().Warn("evaluate dom property", "technology", t.name, "selector", rule.selector, "property", property, "error", err)
 						continue
 					}
-					html, err := dom.GetOuterHTML().WithNodeID(node_res).Do(ctx)
-					if err != nil {
+					if exception != nil {
+						w.log().Debug("evaluate dom property", "technology", t.name, "selector", rule.selector, "property", property, "exception", exception.Text)
 						continue
 					}
-					if len(html) != 0 {
-						w.setFinger(name, value, 100, "")
+					var value string
+					if res.Type != "string" || json.Unmarshal(res.Value, &value) != nil {
+						continue
 					}
-				}
-				break
-			case map[string]map[string]string:
-				for keys, vals := range doms {
-					split := w.split(keys, ",")
-					for i := 0; i < len(split); i++ {
-						node_ress, err := dom.QuerySelectorAll(node.NodeID, split[i]).Do(ctx)
-						if err != nil {
-							w.PrintError(err)
-							continue
-						}
-						for _, node_res := range node_ress {
-							attributes, err := dom.GetAttributes(node_res).Do(ctx)
-							if err != nil {
-								w.PrintError(err)
-								continue
-							}
-							html_text, err := dom.GetOuterHTML().WithNodeID(node_res).Do(ctx)
-							if err != nil {
-								w.PrintError(err)
-								continue
-							}
-							for keys_, vals_ := range vals {
-								switch keys_ {
-								case "exists":
-									w.setFinger(name, value, 100, "")
-									break
-								case "text":
-									w.runRegexp(vals_, html_text, name, value)
-									break
-								case "properties":
-									res, exception, err := runtime.Evaluate("document.querySelector('" + split[i] + "')[\"" + vals_ + "\"]").Do(ctx)
-									if err != nil {
-										w.PrintError(err)
-										continue
-									}
-									if exception != nil {
-										w.PrintError(exception)
-										continue
-									}
-									if !res.Value.IsValid() {
-										continue
-									}
-									w.setFinger(name, value, 100, "")
-									break
-								case "attributes":
-									exist, str := w.getArrayData(attributes, keys)
-									if exist {
-										w.runRegexp(vals_, str, name, value)
-									}
-									break
-								default:
-									w.PrintError("found no dom type", name, err)
-								}
-							}
-						}
+					evidence := Evidence{Source: sourceDOM, Location: rule.selector + "." + property}
+					if p == nil {
+						w.setFinger(t, "dom:"+rule.selector+"."+property, 100, "", evidence)
+						continue
 					}
+					w.runPattern(p, value, t, evidence)
 				}
-				break
-			case map[string]map[string]map[string]string:
-				for keys, vals := range doms {
-					split := w.split(keys, ",")
-					for i := 0; i < len(split); i++ {
-						node_ress, err := dom.QuerySelectorAll(node.NodeID, split[i]).Do(ctx)
-						if err != nil {
-							w.PrintError(err)
-							continue
-						}
-						for _, node_res := range node_ress {
-							attributes, err := dom.GetAttributes(node_res).Do(ctx)
-							if err != nil {
-								w.PrintError(err)
-								continue
-							}
-							html_text, err := dom.GetOuterHTML().WithNodeID(node_res).Do(ctx)
-							if err != nil {
-								w.PrintError(err)
-								continue
-							}
-							for keys_, vals_ := range vals {
-								switch keys_ {
-								case "exists":
-									w.setFinger(name, value, 100, "")
-									break
-								case "text":
-									for _, vals__ := range vals_ {
-										w.runRegexp(vals__, html_text, name, value)
-									}
-									break
-								case "properties":
-									for keys__ := range vals_ {
-										res, exception, err := runtime.Evaluate("document.querySelector('" + split[i] + "')[\"" + keys__ + "\"]").Do(ctx)
-										if err != nil {
-											w.PrintError(err)
-											continue
-										}
-										if exception != nil {
-											w.PrintError(exception)
-											continue
-										}
-										if !res.Value.IsValid() {
-											continue
-										}
-										w.setFinger(name, value, 100, "")
-									}
-									break
-								case "attributes":
-									for keys__, vals__ := range vals_ {
-										exist, str := w.getArrayData(attributes, keys__)
-										if exist {
-											w.runRegexp(vals__, str, name, value)
-										}
-									}
-									break
-								default:
-									w.PrintError("found no dom type", name, err)
-								}
-							}
+				if len(rule.text) == 0 && len(rule.attributes) == 0 {
+					continue
+				}
+				for _, node_res := range node_ress {
+					html_text, err := dom.GetOuterHTML().WithNodeID(node_res).Do(ctx)
+					if err != nil {
+						w.log().Warn("get outer html", "selector", rule.selector, "error", err)
+						continue
+					}
+					w.runPatterns(rule.text, html_text, t, Evidence{Source: sourceDOM, Location: rule.selector})
+					attributes, err := dom.GetAttributes(node_res).Do(ctx)
+					if err != nil {
+						w.log().Warn("get attributes", "selector", rule.selector, "error", err)
+						continue
+					}
+					for attribute, p := range rule.attributes {
+						exist, str := w.getArrayData(attributes, attribute)
+						if exist {
+							w.runPattern(p, str, t, Evidence{Source: sourceDOM, Location: rule.selector + "[" + attribute + "]"})
 						}
 					}
 				}
-				break
-			default:
-				w.PrintError("found no doms type", value.DOM)
-				os.Exit(1)
 			}
 		}
 		return nil
 	})
 }
 
+// 读取第一个匹配节点的属性值，节点或属性不存在时返回 null，字符串、数字、布尔值转为字符串，其他类型返回 "true"
+const domPropertyScript = `(function (selector, property) {
+	try {
+		var node = document.querySelector(selector)
+		if (!node || node[property] === undefined) {
+			return null
+		}
+		var value = node[property]
+		return ['string', 'number', 'boolean'].indexOf(typeof value) !== -1 ? String(value) : 'true'
+	} catch (e) {
+		return null
+	}
+})(%s, %s)`
+
+// 读取变量值，与 wappalyzer 一致按 . 逐级查找，不执行规则中的代码
+// 变量不存在或值为 false、0、"" 等假值时返回 null，字符串、数字转为字符串，其他类型返回 "true"
+const jsValueScript = `(function (chain) {
+	try {
+		var value = chain.split('.').reduce(function (value, key) {
+			return value !== undefined && value !== null && key in Object(value) ? value[key] : undefined
+		}, window)
+		if (!value) {
+			return null
+		}
+		return typeof value === 'string' || typeof value === 'number' ? String(value) : 'true'
+	} catch (e) {
+		return null
+	}
+})(%s)`
+
 // 已测试
 func (w *Wappalyzer) js() chromedp.Action {
-	// 查看是否存在该变量的定义
 	return chromedp.ActionFunc(func(ctx context.Context) error {
-		for name, value := range schemas {
-			if value.JS == nil {
-				continue
-			}
-			// 不需要获取version，暂不提取变量值
-			for variable := range value.JS {
-				res, exception, err := runtime.Evaluate(variable).Do(ctx)
+		globals := make(map[string]string)
+		for _, t := range w.db.index[sourceJS] {
+			for variable := range t.js {
+				if _, ok := globals[variable]; ok {
+					continue
+				}
+				chain, _ := json.Marshal(variable)
+				res, exception, err := runtime.Evaluate(fmt.Sprintf(jsValueScript, chain)).WithReturnByValue(true).Do(ctx)
 				if err != nil {
-					w.PrintError(err)
+					w.log().Warn("evaluate js", "technology", t.name, "variable", variable, "error", err)
 					continue
 				}
 				if exception != nil {
-					w.PrintError(exception)
+					w.log().Debug("evaluate js", "technology", t.name, "variable", variable, "exception", exception.Text)
 					continue
 				}
-				if res.Type == "undefined" {
+				var value string
+				if res.Type != "string" || json.Unmarshal(res.Value, &value) != nil {
 					continue
 				}
-				w.setFinger(name, value, 100, "")
+				globals[variable] = value
 			}
 		}
+		w.jsGlobals(globals)
 		return nil
 	})
 }
 
+// globals 为已存在的 JavaScript 变量 -> 值，值为 false、0、null 等假值时视为不存在
+// 值为 "" 表示只知道变量存在；规则为空时只要求变量存在，否则匹配变量值并提取版本
+func (w *Wappalyzer) jsGlobals(globals map[string]string) {
+	for _, t := range w.db.index[sourceJS] {
+		for variable, patterns := range t.js {
+			value, ok := globals[variable]
+			if !ok || isFalsy(value) {
+				continue
+			}
+			w.runPatterns(patterns, value, t, Evidence{Source: sourceJS, Location: variable})
+		}
+	}
+}
+
+// 外部采集的变量值，"" 表示变量存在，不视为假值
+func isFalsy(value string) bool {
+	switch value {
+	case "false", "0", "null", "undefined", "NaN":
+		return true
+	}
+	return false
+}
+
 // 已测试
 func (w *Wappalyzer) meta() chromedp.Action {
 	return chromedp.ActionFunc(func(ctx context.Context) error {
@@ -547,43 +400,38 @@ func (w *Wappalyzer) meta() chromedp.Action {
 			}
 			attributes = append(attributes, attribute)
 		}
-		for name, value := range schemas {
-			if value.Meta == nil {
-				continue
-			}
-			switch v := TypeDetect(value.Meta).(type) {
-			case map[string]string:
-				for key, val := range v {
-					for i := 0; i < len(attributes); i++ {
-						if w.isArrExist(attributes[i], key) && w.isArrExist(attributes[i], val) {
-							w.setFinger(name, value, 100, "")
-						}
-					}
-				}
-				break
-			case map[string][]string:
-				for key, val := range v {
-					for i := 0; i < len(attributes); i++ {
-						if w.isArrExist(attributes[i], key) {
-							for j := 0; j < len(val); j++ {
-								if w.isArrExist(attributes[i], val[j]) {
-									w.setFinger(name, value, 100, "")
-								}
-							}
-						}
-					}
-				}
-				break
-			default:
-				w.PrintError(name, "meta found no type", value.Meta)
-				os.Exit(1)
-			}
-		}
+		w.metas(attributes)
 		return nil
 	})
 }
 
-// 已测试 TODO: golang 不支持一些正则表达式，已去除，可考虑使用js进行判断
+// attributes 为每个 meta 标签的属性数组，取 name、property 或 http-equiv 作为名称匹配 content
+func (w *Wappalyzer) metas(attributes [][]string) {
+	for i := 0; i < len(attributes); i++ {
+		name, content := "", ""
+		for j := 0; j+1 < len(attributes[i]); j += 2 {
+			switch strings.ToLower(attributes[i][j]) {
+			case "name", "property", "http-equiv":
+				name = attributes[i][j+1]
+			case "content":
+				content = attributes[i][j+1]
+			}
+		}
+		if name != "" {
+			w.metaContent(name, content)
+		}
+	}
+}
+
+// meta 名称不区分大小写
+func (w *Wappalyzer) metaContent(name string, content string) {
+	name = strings.ToLower(name)
+	for _, t := range w.db.index[sourceMeta] {
+		w.runPatterns(t.meta[name], content, t, Evidence{Source: sourceMeta, Location: name})
+	}
+}
+
+// 已测试
 func (w *Wappalyzer) scriptsrc() chromedp.Action {
 	return chromedp.ActionFunc(func(ctx context.Context) error {
 		node, err := dom.GetDocument().Do(ctx)
@@ -602,72 +450,99 @@ func (w *Wappalyzer) scriptsrc() chromedp.Action {
 			}
 			attributes = append(attributes, attribute)
 		}
-		for name, value := range schemas {
-			if value.ScriptSrc == nil {
-				continue
-			}
-			switch v := TypeDetect(value.ScriptSrc).(type) {
-			case string:
-				for i := 0; i < len(attributes); i++ {
-					for j := 0; j < len(attributes[i]); j++ {
-						w.runRegexp(v, attributes[i][j], name, value)
-					}
-				}
-				break
-			case []string:
-				for i := 0; i < len(attributes); i++ {
-					for j := 0; j < len(attributes[i]); j++ {
-						for x := 0; x < len(v); x++ {
-							w.runRegexp(v[x], attributes[i][j], name, value)
-						}
-					}
-				}
-				break
-			default:
-				w.PrintError(name, "script src found no type", value.Meta)
-				os.Exit(1)
+		srcs := make([]string, 0)
+		for i := 0; i < len(attributes); i++ {
+			if exist, src := w.getArrayData(attributes[i], "src"); exist && src != "" {
+				srcs = append(srcs, src)
 			}
 		}
+		w.scriptSrcs(srcs)
 		return nil
 	})
 }
 
-// 已测试
+func (w *Wappalyzer) scriptSrcs(srcs []string) {
+	for _, t := range w.db.index[sourceScriptSrc] {
+		for _, src := range srcs {
+			w.runPatterns(t.scriptSrc, src, t, Evidence{Source: sourceScriptSrc, Location: src})
+		}
+	}
+}
+
+// script 内容，location 为外部脚本的 URL，内联脚本为空
+func (w *Wappalyzer) scriptContent(content string, location string) {
+	for _, t := range w.db.index[sourceScripts] {
+		w.runPatterns(t.scripts, content, t, Evidence{Source: sourceScripts, Location: location})
+	}
+}
+
+// 已测试 - 外部脚本在 DetectListen 中处理
 func (w *Wappalyzer) scripts() chromedp.Action {
 	return chromedp.ActionFunc(func(ctx context.Context) error {
-		for name, value := range schemas {
-			if value.Scripts == nil {
-				continue
+		if len(w.db.index[sourceScripts]) == 0 {
+			return nil
+		}
+		var contents []string
+		err := chromedp.Evaluate(`Array.from(document.scripts).filter(s => !s.src).map(s => s.textContent)`, &contents).Do(ctx)
+		if err != nil {
+			return err
+		}
+		for _, content := range contents {
+			w.scriptContent(content, "")
+		}
+		return nil
+	})
+}
+
+// 页面可见文本
+func (w *Wappalyzer) bodyText() chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		if len(w.db.index[sourceText]) == 0 {
+			return nil
+		}
+		var text string
+		err := chromedp.Evaluate(`document.body ? document.body.innerText : ""`, &text).Do(ctx)
+		if err != nil {
+			return err
+		}
+		w.text(text)
+		return nil
+	})
+}
+
+// <style>、style 属性，StyleSheets 开启时还包括 document.styleSheets 中可读取的规则
+const cssScript = `(sheets => {
+	const styles = Array.from(document.querySelectorAll("style")).map(s => ({location: "style", css: s.textContent}));
+	styles.push({location: "style attribute", css: Array.from(document.querySelectorAll("[style]")).map(e => e.getAttribute("style")).join("\n")});
+	if (sheets) {
+		for (const sheet of Array.from(document.styleSheets)) {
+			try {
+				styles.push({location: sheet.href || "styleSheets", css: Array.from(sheet.cssRules).map(r => r.cssText).join("\n")});
+			} catch (e) {
+				// 跨域样式表无法读取 cssRules
 			}
-			switch val := TypeDetect(value.Scripts).(type) {
-			case string:
-				_, exception, err := runtime.Evaluate(val).Do(ctx)
-				if err != nil {
-					w.PrintError(err)
-					break
-				}
-				if exception != nil {
-					w.PrintError(exception)
-					break
-				}
-				w.setFinger(name, value, 100, "")
-				break
-			case []string:
-				for _, v := range val {
-					_, exception, err := runtime.Evaluate(v).Do(ctx)
-					if err != nil {
-						w.PrintError(err)
-						break
-					}
-					if exception != nil {
-						w.PrintError(exception)
-						break
-					}
-					w.setFinger(name, value, 100, "")
-				}
-				break
-			default:
-				log.Println("found no scripts type", value.Scripts)
+		}
+	}
+	return styles;
+})(%t)`
+
+// 内联 CSS，外部样式表由 DetectListen 处理
+func (w *Wappalyzer) styles() chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		if len(w.db.index[sourceCSS]) == 0 {
+			return nil
+		}
+		var styles []struct {
+			Location string `json:"location"`
+			CSS      string `json:"css"`
+		}
+		err := chromedp.Evaluate(fmt.Sprintf(cssScript, w.StyleSheets), &styles).Do(ctx)
+		if err != nil {
+			return err
+		}
+		for _, style := range styles {
+			if style.CSS != "" {
+				w.css(style.CSS, style.Location)
 			}
 		}
 		return nil
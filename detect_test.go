@@ -0,0 +1,34 @@
+package wappalyzer
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestListenAfterWait(t *testing.T) {
+	w := NewWappalyzer(loadTestDB(t, `{}`))
+	var ran atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 64; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// 与 Wait 并发接收事件
+			w.listen(func() { ran.Add(1) })
+		}()
+	}
+	if err := w.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	done := ran.Load()
+	wg.Wait()
+	w.listen(func() { ran.Add(1) })
+	if err := w.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := ran.Load(); got != done {
+		t.Errorf("ran %d tasks after Wait returned, want none", got-done)
+	}
+}
@@ -0,0 +1,261 @@
+package wappalyzer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/miekg/dns"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 系统配置不可用时使用的服务器
+var defaultDNSServers = []string{"114.114.114.114"}
+
+// CNAME 链最多跟随的层数
+const cnameChainLimit = 8
+
+// 逐类型查询的记录，取值与指纹中 dns 的键一致
+var dnsRecordTypes = map[string]uint16{
+	"A":     dns.TypeA,
+	"AAAA":  dns.TypeAAAA,
+	"MX":    dns.TypeMX,
+	"TXT":   dns.TypeTXT,
+	"SOA":   dns.TypeSOA,
+	"NS":    dns.TypeNS,
+	"CAA":   dns.TypeCAA,
+	"SRV":   dns.TypeSRV,
+	"CNAME": dns.TypeCNAME,
+}
+
+// DNS 解析器，可替换为自定义实现，测试时可指向本地 miekg/dns 服务
+type Resolver interface {
+	Exchange(ctx context.Context, m *dns.Msg) (*dns.Msg, error)
+}
+
+// 普通 DNS / DNS over TLS，按顺序尝试各服务器
+type dnsResolver struct {
+	client  *dns.Client
+	servers []string
+}
+
+// servers 可省略端口，默认 53
+func NewResolver(servers ...string) Resolver {
+	return &dnsResolver{
+		client:  &dns.Client{Timeout: 10 * time.Second},
+		servers: withPort(servers, "53"),
+	}
+}
+
+// DNS over TLS，servers 可省略端口，默认 853
+func NewDoTResolver(servers ...string) Resolver {
+	return &dnsResolver{
+		client:  &dns.Client{Net: "tcp-tls", Timeout: 10 * time.Second},
+		servers: withPort(servers, "853"),
+	}
+}
+
+// 使用 /etc/resolv.conf 中的服务器
+func SystemResolver() (Resolver, error) {
+	config, err := dns.ClientConfigFromFile("/etc/resolv.conf")
+	if err != nil {
+		return nil, err
+	}
+	if len(config.Servers) == 0 {
+		return nil, errors.New("no nameserver in /etc/resolv.conf")
+	}
+	return NewResolver(withPort(config.Servers, config.Port)...), nil
+}
+
+func withPort(servers []string, port string) []string {
+	res := make([]string, 0, len(servers))
+	for _, server := range servers {
+		if _, _, err := net.SplitHostPort(server); err != nil {
+			server = net.JoinHostPort(server, port)
+		}
+		res = append(res, server)
+	}
+	return res
+}
+
+func (r *dnsResolver) Exchange(ctx context.Context, m *dns.Msg) (*dns.Msg, error) {
+	if len(r.servers) == 0 {
+		return nil, errors.New("no dns server")
+	}
+	var err error
+	for _, server := range r.servers {
+		var res *dns.Msg
+		res, _, err = r.client.ExchangeContext(ctx, m, server)
+		// UDP 响应被截断时改用 TCP
+		if err == nil && res.Truncated && r.client.Net == "" {
+			tcp := *r.client
+			tcp.Net = "tcp"
+			res, _, err = tcp.ExchangeContext(ctx, m, server)
+		}
+		if err == nil {
+			return res, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+	return nil, err
+}
+
+// DNS over HTTPS (RFC 8484)
+type dohResolver struct {
+	endpoint string
+	client   *http.Client
+}
+
+// endpoint 如 https://dns.google/dns-query，client 为空时使用默认配置
+func NewDoHResolver(endpoint string, client *http.Client) Resolver {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &dohResolver{endpoint: endpoint, client: client}
+}
+
+func (r *dohResolver) Exchange(ctx context.Context, m *dns.Msg) (*dns.Msg, error) {
+	query := m.Copy()
+	query.Id = 0
+	data, err := query.Pack()
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.endpoint, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/dns-message")
+	req.Header.Set("Accept", "application/dns-message")
+	res, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("doh %s: %s", r.endpoint, res.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(res.Body, dns.MaxMsgSize))
+	if err != nil {
+		return nil, err
+	}
+	answer := new(dns.Msg)
+	if err = answer.Unpack(body); err != nil {
+		return nil, err
+	}
+	answer.Id = m.Id
+	return answer, nil
+}
+
+// 未设置 Resolver 时使用系统配置，读取失败则使用 defaultDNSServers
+func (w *Wappalyzer) resolver() Resolver {
+	if w.Resolver != nil {
+		return w.Resolver
+	}
+	if r, err := SystemResolver(); err == nil {
+		return r
+	}
+	return NewResolver(defaultDNSServers...)
+}
+
+// 并发查询各类型记录并跟随 CNAME 链，部分查询失败时仍匹配已获取的记录
+func (w *Wappalyzer) DetectDNS(ctx context.Context, domain string) error {
+	r := w.resolver()
+	domain = dns.Fqdn(domain)
+	recoards := make(map[string][]string)
+	errs := make([]error, 0)
+	lock := sync.Mutex{}
+	wg := sync.WaitGroup{}
+	for name, qtype := range dnsRecordTypes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var values []string
+			var err error
+			if qtype == dns.TypeCNAME {
+				values, err = cnameChain(ctx, r, domain)
+			} else {
+				values, err = queryRecords(ctx, r, domain, qtype)
+			}
+			lock.Lock()
+			defer lock.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("dns %s %s: %w", name, domain, err))
+			}
+			recoards[name] = append(recoards[name], values...)
+		}()
+	}
+	wg.Wait()
+	w.dnsRecords(recoards)
+	return errors.Join(errs...)
+}
+
+// 返回 domain 的 qtype 记录值，去重后保持应答顺序
+func queryRecords(ctx context.Context, r Resolver, domain string, qtype uint16) ([]string, error) {
+	m := new(dns.Msg)
+	m.SetQuestion(domain, qtype)
+	res, err := r.Exchange(ctx, m)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]string, 0)
+	for _, ans := range res.Answer {
+		if ans.Header().Rrtype != qtype {
+			continue
+		}
+		var value []string
+		switch a := ans.(type) {
+		case *dns.A:
+			value = []string{a.A.String()}
+		case *dns.AAAA:
+			value = []string{a.AAAA.String()}
+		case *dns.MX:
+			value = []string{a.Mx}
+		case *dns.TXT:
+			// 超过 255 字节的记录被拆分为多段
+			value = []string{strings.Join(a.Txt, "")}
+		case *dns.SOA:
+			value = []string{a.Ns}
+		case *dns.NS:
+			value = []string{a.Ns}
+		case *dns.CAA:
+			value = []string{a.Value}
+		case *dns.SRV:
+			value = []string{a.Target}
+		case *dns.CNAME:
+			value = []string{a.Target}
+		}
+		for _, v := range value {
+			if !slices.Contains(values, v) {
+				values = append(values, v)
+			}
+		}
+	}
+	return values, nil
+}
+
+// 依次查询 CNAME 直到没有别名，返回链上的全部目标
+func cnameChain(ctx context.Context, r Resolver, domain string) ([]string, error) {
+	chain := make([]string, 0)
+	name := domain
+	for i := 0; i < cnameChainLimit; i++ {
+		targets, err := queryRecords(ctx, r, name, dns.TypeCNAME)
+		if err != nil {
+			return chain, err
+		}
+		if len(targets) == 0 || strings.EqualFold(targets[0], domain) || slices.Contains(chain, targets[0]) {
+			break
+		}
+		chain = append(chain, targets[0])
+		name = targets[0]
+	}
+	return chain, nil
+}
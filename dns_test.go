@@ -0,0 +1,93 @@
+package wappalyzer
+
+import (
+	"context"
+	"github.com/miekg/dns"
+	"net"
+	"strings"
+	"testing"
+)
+
+// 本地 DNS 服务，www.example.com 经两层 CNAME 指向 cdn
+var testZone = map[uint16][]string{
+	dns.TypeCNAME: {
+		"www.example.com. 60 IN CNAME edge.example.net.",
+		"edge.example.net. 60 IN CNAME www.example.com.cdn.cloudflare.net.",
+	},
+	dns.TypeMX:  {"www.example.com. 60 IN MX 10 aspmx.l.google.com."},
+	dns.TypeNS:  {"www.example.com. 60 IN NS ns1.example.com."},
+	dns.TypeTXT: {`www.example.com. 60 IN TXT "v=spf1 include:_spf.goo" "gle.com ~all"`},
+}
+
+func startDNSServer(t *testing.T) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := dns.HandlerFunc(func(w dns.ResponseWriter, r *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(r)
+		question := r.Question[0]
+		for _, record := range testZone[question.Qtype] {
+			rr, err := dns.NewRR(record)
+			if err != nil {
+				t.Error(err)
+				continue
+			}
+			if strings.EqualFold(rr.Header().Name, question.Name) {
+				m.Answer = append(m.Answer, rr)
+			}
+		}
+		w.WriteMsg(m)
+	})
+	started := make(chan struct{})
+	server := &dns.Server{PacketConn: conn, Handler: handler, NotifyStartedFunc: func() { close(started) }}
+	go server.ActivateAndServe()
+	<-started
+	t.Cleanup(func() { server.Shutdown() })
+	return conn.LocalAddr().String()
+}
+
+func TestDetectDNS(t *testing.T) {
+	db, err := LoadReader(strings.NewReader(`{
+		"technologies": {
+			"Cloudflare": {"cats": [1], "dns": {"CNAME": "\\.cloudflare\\.net"}},
+			"Google Workspace": {"cats": [1], "dns": {"MX": "aspmx\\.l\\.google\\.com"}},
+			"Google SPF": {"cats": [1], "dns": {"TXT": "include:_spf\\.google\\.com"}}
+		},
+		"categories": {"1": {"name": "Miscellaneous"}}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := NewWappalyzer(db)
+	w.Resolver = NewResolver(startDNSServer(t))
+	if err = w.DetectDNS(context.Background(), "www.example.com"); err != nil {
+		t.Fatal(err)
+	}
+	fingers := w.GetFingers()
+	for _, name := range []string{"Cloudflare", "Google Workspace", "Google SPF"} {
+		if _, ok := fingers.Get(name); !ok {
+			t.Errorf("%s not detected", name)
+		}
+	}
+}
+
+func TestCNAMEChain(t *testing.T) {
+	r := NewResolver(startDNSServer(t))
+	chain, err := cnameChain(context.Background(), r, "www.example.com.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"edge.example.net.", "www.example.com.cdn.cloudflare.net."}
+	if strings.Join(chain, ",") != strings.Join(want, ",") {
+		t.Errorf("chain = %v, want %v", chain, want)
+	}
+	records, err := queryRecords(context.Background(), r, "www.example.com.", dns.TypeNS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 {
+		t.Errorf("NS = %v, want 1 record", records)
+	}
+}
@@ -5,10 +5,13 @@ go 1.24
 toolchain go1.24.1
 
 require (
+	github.com/andybalholm/cascadia v1.3.3
 	github.com/chromedp/cdproto v0.0.0-20250311215558-29dfcc2791de
 	github.com/chromedp/chromedp v0.13.1
+	github.com/dlclark/regexp2 v1.11.5
 	github.com/gin-gonic/gin v1.10.0
 	github.com/miekg/dns v1.1.63
+	golang.org/x/net v0.37.0
 )
 
 require (
@@ -41,7 +44,6 @@ require (
 	golang.org/x/arch v0.15.0 // indirect
 	golang.org/x/crypto v0.36.0 // indirect
 	golang.org/x/mod v0.24.0 // indirect
-	golang.org/x/net v0.37.0 // indirect
 	golang.org/x/sync v0.12.0 // indirect
 	golang.org/x/sys v0.31.0 // indirect
 	golang.org/x/text v0.23.0 // indirect
@@ -0,0 +1,107 @@
+package wappalyzer
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// HAR 1.2 中用到的字段
+type harFile struct {
+	Log struct {
+		Entries []harEntry `json:"entries"`
+	} `json:"log"`
+}
+
+type harEntry struct {
+	ResourceType string `json:"_resourceType"` // chrome devtools 导出
+	Request      struct {
+		URL     string         `json:"url"`
+		Headers []harNameValue `json:"headers"`
+		Cookies []harNameValue `json:"cookies"`
+	} `json:"request"`
+	Response struct {
+		Status  int            `json:"status"`
+		Headers []harNameValue `json:"headers"`
+		Cookies []harNameValue `json:"cookies"`
+		Content struct {
+			MimeType string `json:"mimeType"`
+			Text     string `json:"text"`
+			Encoding string `json:"encoding"`
+		} `json:"content"`
+	} `json:"response"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// 离线分析 HAR 文件(Burp、浏览器开发者工具导出)，返回结果与 GetFingers 相同
+func (w *Wappalyzer) DetectHAR(r io.Reader) (Result, error) {
+	var har harFile
+	err := json.NewDecoder(r).Decode(&har)
+	if err != nil {
+		return Result{}, err
+	}
+	for _, entry := range har.Log.Entries {
+		w.harEntry(entry)
+	}
+	return w.GetFingers(), nil
+}
+
+func (w *Wappalyzer) harEntry(entry harEntry) {
+	req_url := entry.Request.URL
+	headers := make(map[string][]string)
+	for _, header := range entry.Response.Headers {
+		headers[header.Name] = append(headers[header.Name], header.Value)
+	}
+	w.headers(headers)
+	cookies := make(map[string]string)
+	for _, cookie := range entry.Request.Cookies {
+		cookies[cookie.Name] = cookie.Value
+	}
+	for _, cookie := range entry.Response.Cookies {
+		cookies[cookie.Name] = cookie.Value
+	}
+	w.cookies(cookies)
+
+	if isXHR(entry) {
+		w.xhr(req_url)
+	}
+	body := entry.Response.Content.Text
+	if entry.Response.Content.Encoding == "base64" {
+		decoded, err := base64.StdEncoding.DecodeString(body)
+		if err != nil {
+			w.log().Warn("decode har content", "url", req_url, "error", err)
+			return
+		}
+		body = string(decoded)
+	}
+	mime_type := strings.ToLower(entry.Response.Content.MimeType)
+	switch {
+	case entry.ResourceType == "document" || strings.Contains(mime_type, "html"):
+		w.url(req_url)
+		if body != "" {
+			w.analyzeHTML(body)
+		}
+	case entry.ResourceType == "stylesheet" || strings.Contains(mime_type, "css"):
+		w.css(body, req_url)
+	case entry.ResourceType == "script" || strings.Contains(mime_type, "javascript"):
+		w.scriptSrcs([]string{req_url})
+		w.scriptContent(body, req_url)
+	}
+}
+
+func isXHR(entry harEntry) bool {
+	if entry.ResourceType == "xhr" || entry.ResourceType == "fetch" {
+		return true
+	}
+	for _, header := range entry.Request.Headers {
+		if strings.EqualFold(header.Name, "X-Requested-With") && header.Value == "XMLHttpRequest" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,171 @@
+package wappalyzer
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"github.com/andybalholm/cascadia"
+	"golang.org/x/net/html"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// HTTP 模式读取响应体的最大长度
+const httpBodyLimit = 5 << 20
+
+func newHTTPClient() *http.Client {
+	return &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{
+				InsecureSkipVerify: true,
+			},
+		},
+		Timeout: 10 * time.Second,
+	}
+}
+
+// 不启动浏览器，直接请求 req_url 并分析静态响应
+// 无法检测 js 变量、properties 等需要执行 JavaScript 的规则
+func (w *Wappalyzer) DetectHTTP(ctx context.Context, req_url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, req_url, nil)
+	if err != nil {
+		return err
+	}
+	res, err := newHTTPClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(res.Body, httpBodyLimit))
+	if err != nil {
+		return err
+	}
+	w.AnalyzeResponse(res, body)
+	return nil
+}
+
+// 分析 HTTP 响应：url、headers、Set-Cookie、TLS 证书及 HTML 中的 html、text、meta、scriptSrc、内联 script、dom
+func (w *Wappalyzer) AnalyzeResponse(res *http.Response, body []byte) {
+	w.analyze(responsePage(res, body))
+}
+
+func isHTML(content_type string, body []byte) bool {
+	if content_type != "" {
+		return strings.Contains(content_type, "html")
+	}
+	return strings.Contains(http.DetectContentType(body), "html")
+}
+
+// 解析静态 HTML，匹配除 url、headers、cookies 外的规则
+func (w *Wappalyzer) analyzeHTML(body string) {
+	w.html(body)
+	root, err := html.Parse(strings.NewReader(body))
+	if err != nil {
+		w.log().Warn("parse html", "error", err)
+		return
+	}
+	metas := make([][]string, 0)
+	srcs := make([]string, 0)
+	scripts := make([]string, 0)
+	styles := make([]string, 0)
+	attributes := make([]string, 0)
+	var text strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			text.WriteString(n.Data)
+			text.WriteString(" ")
+		}
+		if n.Type == html.ElementNode {
+			if style, ok := nodeAttribute(n, "style"); ok {
+				attributes = append(attributes, style)
+			}
+			switch n.Data {
+			case "meta":
+				metas = append(metas, nodeAttributes(n))
+			case "script":
+				if src, ok := nodeAttribute(n, "src"); ok {
+					srcs = append(srcs, src)
+				} else if n.FirstChild != nil {
+					scripts = append(scripts, n.FirstChild.Data)
+				}
+				return
+			case "style":
+				if n.FirstChild != nil {
+					styles = append(styles, n.FirstChild.Data)
+				}
+				return
+			case "noscript", "template":
+				return
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(root)
+	w.text(text.String())
+	w.metas(metas)
+	w.scriptSrcs(srcs)
+	for _, script := range scripts {
+		w.scriptContent(script, "")
+	}
+	for _, style := range styles {
+		w.css(style, "style")
+	}
+	if len(attributes) > 0 {
+		w.css(strings.Join(attributes, "\n"), "style attribute")
+	}
+	w.domStatic(root)
+}
+
+// 使用 cascadia 匹配 DOM 规则，properties 需要执行 JavaScript，此处跳过
+func (w *Wappalyzer) domStatic(root *html.Node) {
+	for _, t := range w.db.index[sourceDOM] {
+		for _, rule := range t.dom {
+			if rule.sel == nil {
+				continue
+			}
+			nodes := cascadia.QueryAll(root, rule.sel)
+			if len(nodes) == 0 {
+				continue
+			}
+			if rule.exists {
+				w.setFinger(t, rule, 100, "", Evidence{Source: sourceDOM, Location: rule.selector})
+			}
+			for _, node := range nodes {
+				if len(rule.text) != 0 {
+					var outer bytes.Buffer
+					if err := html.Render(&outer, node); err == nil {
+						w.runPatterns(rule.text, outer.String(), t, Evidence{Source: sourceDOM, Location: rule.selector})
+					}
+				}
+				for attribute, p := range rule.attributes {
+					if value, ok := nodeAttribute(node, attribute); ok {
+						w.runPattern(p, value, t, Evidence{Source: sourceDOM, Location: rule.selector + "[" + attribute + "]"})
+					}
+				}
+			}
+		}
+	}
+}
+
+// 与 dom.GetAttributes 的格式一致：[name1, value1, name2, value2 ...]
+func nodeAttributes(n *html.Node) []string {
+	attributes := make([]string, 0, len(n.Attr)*2)
+	for _, attr := range n.Attr {
+		attributes = append(attributes, attr.Key, attr.Val)
+	}
+	return attributes
+}
+
+func nodeAttribute(n *html.Node, key string) (string, bool) {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			return attr.Val, true
+		}
+	}
+	return "", false
+}
@@ -1,9 +1,32 @@
 package wappalyzer
 
-import "fmt"
+import (
+	"log/slog"
+	"sync/atomic"
+)
 
-func (w *Wappalyzer) PrintError(a ...interface{}) {
-	if w.displayError {
-		fmt.Println(a...)
+// 加载指纹库及 Wappalyzer.Logger 为空时使用，未设置时使用 slog.Default()
+// 后台 Reload 时也会读取，因此使用原子操作
+var std_logger atomic.Pointer[slog.Logger]
+
+// 替换包级日志，传入 nil 时丢弃全部日志，可与扫描、Reload 并发调用
+func SetLogger(logger *slog.Logger) {
+	if logger == nil {
+		logger = slog.New(slog.DiscardHandler)
+	}
+	std_logger.Store(logger)
+}
+
+func stdLogger() *slog.Logger {
+	if logger := std_logger.Load(); logger != nil {
+		return logger
+	}
+	return slog.Default()
+}
+
+func (w *Wappalyzer) log() *slog.Logger {
+	if w.Logger != nil {
+		return w.Logger
 	}
+	return stdLogger()
 }
@@ -0,0 +1,112 @@
+package wappalyzer
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 可热更新的指纹库
+// Reload 先完整解析、校验新库，成功后原子替换；已创建的 Wappalyzer 持有旧库，扫描不受影响
+type DBStore struct {
+	db   atomic.Pointer[DB]
+	load func() (*DB, error)
+	lock sync.Mutex
+}
+
+// load 用于加载指纹库，创建时会执行一次
+func NewDBStore(load func() (*DB, error)) (*DBStore, error) {
+	db, err := load()
+	if err != nil {
+		return nil, err
+	}
+	s := &DBStore{load: load}
+	s.db.Store(db)
+	return s, nil
+}
+
+// 当前指纹库
+func (s *DBStore) DB() *DB {
+	return s.db.Load()
+}
+
+// 使用当前指纹库创建扫描
+func (s *DBStore) NewWappalyzer() *Wappalyzer {
+	return NewWappalyzer(s.DB())
+}
+
+// 重新加载指纹库，失败时保留旧库
+func (s *DBStore) Reload() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	db, err := s.load()
+	if err != nil {
+		return err
+	}
+	s.db.Store(db)
+	return nil
+}
+
+// 每隔 interval 检查 dir 下的文件，有变化时执行 Reload，callback 接收每次 Reload 的结果
+// 阻塞直到 ctx 结束
+func (s *DBStore) Watch(ctx context.Context, dir string, interval time.Duration, callback func(error)) error {
+	if interval <= 0 {
+		return fmt.Errorf("invalid watch interval %s", interval)
+	}
+	last, err := dirStamp(dir)
+	if err != nil {
+		return err
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		stamp, err := dirStamp(dir)
+		if err != nil {
+			if callback != nil {
+				callback(err)
+			}
+			continue
+		}
+		if stamp == last {
+			continue
+		}
+		last = stamp
+		err = s.Reload()
+		if callback != nil {
+			callback(err)
+		}
+	}
+}
+
+// 根据文件路径、大小、修改时间计算目录指纹
+func dirStamp(dir string) (uint64, error) {
+	h := fnv.New64a()
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(h, "%s|%d|%d\n", path, info.Size(), info.ModTime().UnixNano())
+		return err
+	})
+	return h.Sum64(), err
+}
@@ -0,0 +1,163 @@
+package wappalyzer
+
+import (
+	"slices"
+	"sort"
+)
+
+// 处理 implies、MinConfidence、requires 及 excludes
+// excludes 只取本轮保留下来的指纹，被 MinConfidence 或 requires 删除的指纹不排除其他指纹
+// 被删除的检测结果推导出的指纹也需删除，因此每轮从剩余的检测结果重新推导，直到没有检测结果被删除
+func (w *Wappalyzer) resolve(detected map[string]Technologie, min_confidence int) map[string]Technologie {
+	for {
+		techs := cloneTechnologies(detected)
+		filterConfidence(techs, min_confidence)
+		w.implies(techs)
+		filterConfidence(techs, min_confidence)
+		w.requires(techs)
+		w.excludes(techs)
+		w.requires(techs)
+		removed := false
+		for name := range detected {
+			if _, ok := techs[name]; !ok {
+				delete(detected, name)
+				removed = true
+			}
+		}
+		if !removed {
+			return techs
+		}
+	}
+}
+
+// 删除 confidence 低于 min_confidence 的指纹
+func filterConfidence(techs map[string]Technologie, min_confidence int) {
+	for name, value := range techs {
+		if value.Confidence < min_confidence {
+			delete(techs, name)
+		}
+	}
+}
+
+// 复制指纹及其切片，推导时追加 ImpliedBy、Evidence 不影响原数据
+func cloneTechnologies(techs map[string]Technologie) map[string]Technologie {
+	res := make(map[string]Technologie, len(techs))
+	for name, value := range techs {
+		value.Categories = slices.Clone(value.Categories)
+		value.ImpliedBy = slices.Clone(value.ImpliedBy)
+		value.Evidence = slices.Clone(value.Evidence)
+		res[name] = value
+	}
+	return res
+}
+
+// 删除依赖的指纹或分类未被检测到的指纹，删除后可能导致其他指纹的依赖不满足，循环直到结果不再变化
+func (w *Wappalyzer) requires(techs map[string]Technologie) {
+	for {
+		categories := make(map[int]bool)
+		for name := range techs {
+			if t, ok := w.db.technologies[name]; ok {
+				for _, cat := range t.props.Cats {
+					categories[cat] = true
+				}
+			}
+		}
+		removed := false
+		for name := range techs {
+			t, ok := w.db.technologies[name]
+			if !ok {
+				continue
+			}
+			if len(t.requires) != 0 && !anyDetected(techs, t.requires) {
+				delete(techs, name)
+				removed = true
+				continue
+			}
+			if len(t.requiresCategory) != 0 && !anyCategory(categories, t.requiresCategory) {
+				delete(techs, name)
+				removed = true
+			}
+		}
+		if !removed {
+			return
+		}
+	}
+}
+
+func anyDetected(techs map[string]Technologie, names []string) bool {
+	for _, name := range names {
+		if _, ok := techs[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func anyCategory(categories map[int]bool, ids []int) bool {
+	for _, id := range ids {
+		if categories[id] {
+			return true
+		}
+	}
+	return false
+}
+
+// 删除被已检测指纹排除的指纹，先按名称顺序收集再统一删除，结果与 map 遍历顺序无关
+func (w *Wappalyzer) excludes(techs map[string]Technologie) {
+	names := make([]string, 0, len(techs))
+	for name := range techs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	excluded := make([]string, 0)
+	for _, name := range names {
+		if t, ok := w.db.technologies[name]; ok {
+			excluded = append(excluded, t.excludes...)
+		}
+	}
+	for _, name := range excluded {
+		delete(techs, name)
+	}
+}
+
+// 递归添加推导出的指纹，confidence 取推导链上的最小值
+// 已存在的指纹只记录来源，不再重复展开，避免循环推导
+func (w *Wappalyzer) implies(techs map[string]Technologie) {
+	queue := make([]string, 0, len(techs))
+	for name := range techs {
+		queue = append(queue, name)
+	}
+	sort.Strings(queue)
+	for len(queue) != 0 {
+		name := queue[0]
+		queue = queue[1:]
+		t, ok := w.db.technologies[name]
+		if !ok {
+			continue
+		}
+		source := techs[name]
+		for _, imp := range t.implies {
+			if imp.name == name {
+				continue
+			}
+			evidence := Evidence{Source: sourceImplied, Pattern: imp.raw, Match: imp.name, Location: name}
+			if exist, ok := techs[imp.name]; ok {
+				if !w.isArrExist(exist.ImpliedBy, name) {
+					exist.ImpliedBy = append(exist.ImpliedBy, name)
+					exist.Evidence = appendEvidence(exist.Evidence, evidence)
+					techs[imp.name] = exist
+				}
+				continue
+			}
+			it, ok := w.db.technologies[imp.name]
+			if !ok {
+				continue
+			}
+			technologie := it.newTechnologie(min(imp.confidence, source.Confidence), imp.version)
+			technologie.ImpliedBy = []string{name}
+			technologie.Evidence = []Evidence{evidence}
+			techs[imp.name] = technologie
+			queue = append(queue, imp.name)
+		}
+	}
+}
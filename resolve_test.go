@@ -0,0 +1,236 @@
+package wappalyzer
+
+import (
+	"strings"
+	"testing"
+)
+
+// technologies 为 technologies 目录中的 JSON 对象，分类固定为 1-3
+func loadTestDB(t *testing.T, technologies string) *DB {
+	t.Helper()
+	db, err := LoadReader(strings.NewReader(`{
+		"technologies": ` + technologies + `,
+		"categories": {"1": {"name": "CMS"}, "2": {"name": "Ecommerce"}, "3": {"name": "Programming languages"}}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// 返回排序后的指纹名称，以逗号连接
+func fingerNames(result Result) string {
+	names := make([]string, 0, len(result.Technologies))
+	for _, tech := range result.Technologies {
+		names = append(names, tech.Name)
+	}
+	return strings.Join(names, ",")
+}
+
+func TestRequires(t *testing.T) {
+	tests := []struct {
+		name         string
+		technologies string
+		html         string
+		want         string
+	}{
+		{
+			name: "requires met",
+			technologies: `{
+				"Shopify": {"cats": [2], "html": "shopify-"},
+				"Plugin": {"cats": [1], "html": "plugin-", "requires": "Shopify"}
+			}`,
+			html: "<p>shopify- plugin-</p>",
+			want: "Plugin,Shopify",
+		},
+		{
+			name: "requires fixpoint",
+			technologies: `{
+				"A": {"cats": [1], "html": "never"},
+				"B": {"cats": [1], "html": "b-", "requires": "A"},
+				"C": {"cats": [1], "html": "c-", "requires": "B"}
+			}`,
+			html: "<p>b- c-</p>",
+			want: "",
+		},
+		{
+			name: "requires any of",
+			technologies: `{
+				"A": {"cats": [1], "html": "a-"},
+				"B": {"cats": [1], "html": "b-", "requires": ["Missing", "A"]}
+			}`,
+			html: "<p>a- b-</p>",
+			want: "A,B",
+		},
+		{
+			name: "requiresCategory",
+			technologies: `{
+				"Shop": {"cats": [2], "html": "shop-"},
+				"Theme": {"cats": [1], "html": "theme-", "requiresCategory": 2},
+				"Widget": {"cats": [1], "html": "widget-", "requiresCategory": [3]}
+			}`,
+			html: "<p>shop- theme- widget-</p>",
+			want: "Shop,Theme",
+		},
+		{
+			name: "requiresCategory fixpoint",
+			technologies: `{
+				"Theme": {"cats": [2], "html": "theme-", "requires": "Missing"},
+				"Widget": {"cats": [1], "html": "widget-", "requiresCategory": 2}
+			}`,
+			html: "<p>theme- widget-</p>",
+			want: "",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := NewWappalyzer(loadTestDB(t, test.technologies))
+			w.html(test.html)
+			if got := fingerNames(w.GetFingers()); got != test.want {
+				t.Errorf("result = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestImplies(t *testing.T) {
+	db := loadTestDB(t, `{
+		"A": {"cats": [1], "html": "a-", "implies": "B\\;confidence:50"},
+		"B": {"cats": [1], "implies": ["C\\;version:2", "A"]},
+		"C": {"cats": [1], "implies": "B"},
+		"Plugin": {"cats": [1], "html": "plugin-", "requires": "C"}
+	}`)
+	w := NewWappalyzer(db)
+	w.html("<p>a- plugin-</p>")
+	result := w.GetFingers()
+	if got := fingerNames(result); got != "A,B,C,Plugin" {
+		t.Fatalf("result = %q, want A,B,C,Plugin", got)
+	}
+	tests := []struct {
+		name       string
+		confidence int
+		version    string
+		impliedBy  string
+	}{
+		{"A", 100, "", "B"},
+		{"B", 50, "", "A,C"},
+		{"C", 50, "2", "B"},
+	}
+	for _, test := range tests {
+		finger, _ := result.Get(test.name)
+		if finger.Confidence != test.confidence || finger.Version != test.version || strings.Join(finger.ImpliedBy, ",") != test.impliedBy {
+			t.Errorf("%s = %d, %q, %v, want %d, %q, %s", test.name, finger.Confidence, finger.Version, finger.ImpliedBy, test.confidence, test.version, test.impliedBy)
+		}
+	}
+}
+
+func TestConfidence(t *testing.T) {
+	db := loadTestDB(t, `{
+		"A": {"cats": [1], "html": ["a-\\;confidence:30", "a-a\\;confidence:30"]},
+		"B": {"cats": [1], "html": ["b-\\;confidence:60", "b-b\\;confidence:60"]},
+		"C": {"cats": [1], "html": "c-\\;confidence:30", "headers": {"X-C": "c\\;confidence:30"}},
+		"D": {"cats": [1], "html": "d-"}
+	}`)
+	tests := []struct {
+		name          string
+		minConfidence int
+		want          map[string]int
+	}{
+		{"sum", 0, map[string]int{"A": 60, "B": 100, "C": 60, "D": 100}},
+		{"min confidence", 61, map[string]int{"B": 100, "D": 100}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := NewWappalyzer(db)
+			w.MinConfidence = test.minConfidence
+			// 同一规则多次命中只计一次
+			w.html("<p>a-a a-a b-b c- c- d-</p>")
+			w.headers(map[string][]string{"x-c": {"c"}})
+			result := w.GetFingers()
+			if len(result.Technologies) != len(test.want) {
+				t.Errorf("result = %q, want %d fingers", fingerNames(result), len(test.want))
+			}
+			for name, confidence := range test.want {
+				if got, _ := result.Get(name); got.Confidence != confidence {
+					t.Errorf("%s confidence = %d, want %d", name, got.Confidence, confidence)
+				}
+			}
+		})
+	}
+}
+
+func TestRequiresDropsImplied(t *testing.T) {
+	db := loadTestDB(t, `{
+		"Shopify": {"cats": [2], "meta": {"shopify-checkout-api-token": ""}},
+		"Plugin": {"cats": [1], "scriptSrc": "plugin\\.js", "requires": "Shopify", "implies": "PHP"},
+		"PHP": {"cats": [3]}
+	}`)
+	result := NewWappalyzer(db).Analyze(PageData{ScriptSrcs: []string{"/static/plugin.js"}})
+	if names := fingerNames(result); names != "" {
+		t.Errorf("result = %q, want empty", names)
+	}
+}
+
+func TestExcludesDeterministic(t *testing.T) {
+	db := loadTestDB(t, `{
+		"A": {"cats": [1], "html": "a", "excludes": "B"},
+		"B": {"cats": [1], "html": "b", "excludes": "C"},
+		"C": {"cats": [1], "html": "c"}
+	}`)
+	for i := 0; i < 50; i++ {
+		result := NewWappalyzer(db).Analyze(PageData{HTML: "<p>a b c</p>"})
+		if names := fingerNames(result); names != "A" {
+			t.Fatalf("result = %q, want A", names)
+		}
+	}
+}
+
+func TestExcludesFromSurvivors(t *testing.T) {
+	tests := []struct {
+		name          string
+		technologies  string
+		html          string
+		minConfidence int
+		want          string
+	}{
+		{
+			name: "excluder dropped by requires",
+			technologies: `{
+				"A": {"cats": [1], "html": "a-", "excludes": "B", "requires": "C"},
+				"B": {"cats": [1], "html": "b-"},
+				"C": {"cats": [1], "html": "never"}
+			}`,
+			html: "<p>a- b-</p>",
+			want: "B",
+		},
+		{
+			name: "excluder dropped by MinConfidence",
+			technologies: `{
+				"A": {"cats": [1], "html": "a-\\;confidence:10", "excludes": "B"},
+				"B": {"cats": [1], "html": "b-"}
+			}`,
+			html:          "<p>a- b-</p>",
+			minConfidence: 50,
+			want:          "B",
+		},
+		{
+			name: "excluded requirement",
+			technologies: `{
+				"A": {"cats": [1], "html": "a-", "excludes": "B"},
+				"B": {"cats": [1], "html": "b-"},
+				"Plugin": {"cats": [1], "html": "plugin-", "requires": "B"}
+			}`,
+			html: "<p>a- b- plugin-</p>",
+			want: "A",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := NewWappalyzer(loadTestDB(t, test.technologies))
+			w.MinConfidence = test.minConfidence
+			if got := fingerNames(w.Analyze(PageData{HTML: test.html})); got != test.want {
+				t.Errorf("result = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
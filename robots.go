@@ -0,0 +1,84 @@
+package wappalyzer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+)
+
+// robots.txt 默认读取的最大长度
+const robotsBodyLimit = 512 << 10
+
+// DetectRobots 的请求配置，零值可用
+type RobotsOptions struct {
+	Client    *http.Client      // 为空时使用默认客户端(忽略证书错误，10s 超时)
+	Transport http.RoundTripper // Client 为空时使用，如代理
+	UserAgent string
+	BodyLimit int64 // 默认 robotsBodyLimit
+}
+
+func (o *RobotsOptions) client() *http.Client {
+	if o.Client != nil {
+		return o.Client
+	}
+	cli := newHTTPClient()
+	if o.Transport != nil {
+		cli.Transport = o.Transport
+	}
+	return cli
+}
+
+// 请求 req_url 所在站点的 /robots.txt，不存在或返回 HTML 页面(soft 404)时不做匹配
+func (w *Wappalyzer) DetectRobots(ctx context.Context, req_url string, opts *RobotsOptions) error {
+	if opts == nil {
+		opts = &RobotsOptions{}
+	}
+	parse, err := url.Parse(req_url)
+	if err != nil {
+		return err
+	}
+	robots_url := url.URL{Scheme: parse.Scheme, Host: parse.Host, Path: "/robots.txt"}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, robots_url.String(), nil)
+	if err != nil {
+		return err
+	}
+	if opts.UserAgent != "" {
+		req.Header.Set("User-Agent", opts.UserAgent)
+	}
+	res, err := opts.client().Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	switch {
+	case res.StatusCode == http.StatusNotFound || res.StatusCode == http.StatusGone:
+		return nil
+	case res.StatusCode != http.StatusOK:
+		return fmt.Errorf("robots.txt %s: %s", req.URL, res.Status)
+	}
+	limit := opts.BodyLimit
+	if limit <= 0 {
+		limit = robotsBodyLimit
+	}
+	body, err := io.ReadAll(io.LimitReader(res.Body, limit))
+	if err != nil {
+		return err
+	}
+	if !isRobots(res.Header.Get("Content-Type"), body) {
+		return nil
+	}
+	w.robots(string(body), req.URL.String())
+	return nil
+}
+
+// 只接受 text/plain，未声明类型时根据内容判断
+func isRobots(content_type string, body []byte) bool {
+	if content_type == "" {
+		return !isHTML("", body)
+	}
+	media_type, _, err := mime.ParseMediaType(content_type)
+	return err == nil && media_type == "text/plain"
+}
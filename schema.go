@@ -1,7 +1,6 @@
 package wappalyzer
 
 import (
-	"errors"
 	"fmt"
 )
 
@@ -34,6 +33,7 @@ type Properties struct {
 	Meta             map[string]interface{} `json:"meta"`             // HTML meta tags - string/array
 	ScriptSrc        interface{}            `json:"scriptSrc"`        // Script Src
 	Scripts          interface{}            `json:"scripts"`          // 执行JavaScript代码
+	CertIssuer       interface{}            `json:"certIssuer"`       // TLS 证书颁发者
 }
 
 func TypeDetect(inf interface{}) interface{} {
@@ -65,7 +65,7 @@ func TypeDetect(inf interface{}) interface{} {
 				strstrstrstr[key] = v
 				break
 			default:
-				fmt.Println("unknown object", val)
+				stdLogger().Debug("unknown object", "value", val)
 			}
 		}
 		if len(strstr) != 0 {
@@ -93,7 +93,7 @@ func TypeDetect(inf interface{}) interface{} {
 				floarr = append(floarr, v)
 				break
 			default:
-				fmt.Println("unknown interface", v)
+				stdLogger().Debug("unknown interface", "value", v)
 				break
 			}
 		}
@@ -115,19 +115,8 @@ func TypeDetect(inf interface{}) interface{} {
 		return float64_
 	}
 	// 如果测试到达此步，需要调整上面代码
-	// 以下代码，对未知数据进行基础类型测试
-	switch val := inf.(type) {
-	case int:
-		fmt.Println("int", val)
-		break
-	case float32:
-		fmt.Println("float32", val)
-		break
-	case float64:
-		fmt.Println("float64", val)
-		break
-	}
-	fmt.Println("type detect unknown", inf)
+	// 记录未知数据的类型
+	stdLogger().Debug("type detect unknown", "type", fmt.Sprintf("%T", inf), "value", inf)
 	return nil
 }
 
@@ -152,7 +141,6 @@ func TypeTest(data interface{}) (string, error) {
 	case map[string]map[string]map[string]string:
 		return "map string string string string", nil
 	default:
-		fmt.Println(data)
-		return "", errors.New("unknown type")
+		return "", fmt.Errorf("unknown type %v", data)
 	}
 }
@@ -1,160 +1,116 @@
 package wappalyzer
 
 import (
-	regexp2 "regexp"
 	"strconv"
 	"strings"
 )
 
-var numbers = []string{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9"}
-
-// 判断子字符串在母字符串中的下标 -> strings的会出错
-// "©" 的长度为2，len判断为2
-func (w *Wappalyzer) index(s, subStr string) int {
-	slice := strings.Split(s, "")
-	subSlice := strings.Split(subStr, "")
-	start := -1
-	for i := 0; i < len(slice); i++ {
-		if i+len(subSlice) == len(slice) {
-			break
-		}
-		var j = 0
-		for j = 0; j < len(subSlice); j++ {
-			if slice[i+j] != subSlice[j] {
-				break
-			}
-		}
-		if j == len(subSlice) {
-			start = i
-			break
-		}
+// evidence 只需填写 Source、Location
+func (w *Wappalyzer) runPattern(p *pattern, data string, t *technology, evidence Evidence) {
+	exist, version, confidence, matched := p.match(data)
+	if exist {
+		evidence.Pattern = p.raw
+		evidence.Match = truncate(matched)
+		w.setFinger(t, p, confidence, version, evidence)
 	}
-	return start
 }
 
-func (w *Wappalyzer) regexp(regexp string, data string) (exist bool, version_ string, confidence int) {
-	regexp, confidence, err := w.getConfidence(regexp)
-	if err != nil {
-		w.PrintError(err)
-		return false, "", confidence
-	}
-
-	regexp, version, err := w.getVersion(regexp)
-	if err != nil {
-		w.PrintError(err)
-		return false, "", confidence
-	}
-
-	// TODO: panic: regexp: Compile(`sites\/(?!default|all).*\/files`): error parsing regexp: invalid or unsupported Perl syntax: `(?!`
-	compile, err := regexp2.Compile(regexp)
-	if err != nil {
-		w.PrintError(err)
-		return false, "", confidence
-	}
-
-	matchs := compile.FindStringSubmatch(data)
-	if len(matchs) == 0 {
-		return false, "", confidence
+func (w *Wappalyzer) runPatterns(patterns []*pattern, data string, t *technology, evidence Evidence) {
+	for _, p := range patterns {
+		w.runPattern(p, data, t, evidence)
 	}
-	if len(matchs) > version {
-		return true, matchs[version], confidence
-	}
-	return true, "", confidence
 }
 
-func (w *Wappalyzer) getVersion(regexp string) (regexp_ string, version int, err error) {
-	regexp_slice := strings.Split(regexp, "")
-	start := w.index(regexp, "\\;version:")
-	if start == -1 {
-		return regexp, 0, nil
-	}
-	end := start + 10
-
-	for i := end; i < len(regexp_slice); i++ {
-		end++
-		if regexp_slice[i] == "\\" {
-			break
-		}
-	}
-
-	// 直到最后或者遇到 \; 暂不考虑获取version
-	ver_num := "1"
-	for i := end; i < len(regexp_slice); i++ {
-		end++
-		if (i+1 == len(regexp_slice)) || (regexp_slice[i+1] == "\\" && regexp_slice[i+2] == ";") {
-			break
-		}
-	}
+// key 为命中的规则，*pattern 或描述检测项的字符串
+// confidence 为不同规则之和，最大 100；同一规则多次命中只计一次
+func (w *Wappalyzer) setFinger(t *technology, key interface{}, confidence int, version string, evidence Evidence) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	hits, ok := w.confidences[t.name]
+	if !ok {
+		hits = make(map[interface{}]int)
+		w.confidences[t.name] = hits
+	}
+	hits[key] = confidence
+	total := 0
+	for _, c := range hits {
+		total += c
+	}
+	total = min(total, 100)
+	technologie, ok := w.technologies[t.name]
+	if !ok {
+		technologie = t.newTechnologie(total, "")
+	}
+	technologie.Confidence = total
+	if betterVersion(version, technologie.Version) {
+		technologie.Version = version
+	}
+	technologie.Evidence = appendEvidence(technologie.Evidence, evidence)
+	w.technologies[t.name] = technologie
+}
 
-	ext := ""
-	if end != len(regexp_slice) && regexp_slice[end] == "?" {
-		for i := end; i < len(regexp_slice); i++ {
-			ext += regexp_slice[i]
-			end++
+// 相同来源、规则、位置的证据只保留一条
+func appendEvidence(evidences []Evidence, evidence Evidence) []Evidence {
+	for _, e := range evidences {
+		if e.Source == evidence.Source && e.Pattern == evidence.Pattern && e.Location == evidence.Location {
+			return evidences
 		}
 	}
-
-	regexp = strings.Join(regexp_slice[:start], "") + strings.Join(regexp_slice[end:], "")
-	version, err = strconv.Atoi(ver_num)
-	return regexp, version, err
+	return append(evidences, evidence)
 }
 
-func (w *Wappalyzer) getConfidence(regexp string) (regexp_ string, confidence int, err error) {
-	regexp_slice := strings.Split(regexp, "")
-	start := w.index(regexp, "\\;confidence:")
-	if start == -1 {
-		return regexp, 0, err
+// 截断过长的匹配内容
+func truncate(s string) string {
+	runes := []rune(s)
+	if len(runes) <= evidenceMatchLimit {
+		return s
 	}
-	end := start + 13
-	ver_num := ""
-	for i := end; i < len(regexp_slice); i++ {
-		if w.isArrExist(numbers, regexp_slice[i]) {
-			ver_num += regexp_slice[i]
-			end++
-			continue
-		}
-		break
-	}
-	regexp = strings.Join(regexp_slice[:start], "") + strings.Join(regexp_slice[end:], "")
-	confidence, err = strconv.Atoi(ver_num)
-	return regexp, confidence, err
+	return string(runes[:evidenceMatchLimit]) + "..."
 }
 
-func (w *Wappalyzer) runRegexp(regexp string, data string, name string, product Properties) {
-	exist, version, confidence := w.regexp(regexp, data)
-	if exist {
-		w.setFinger(name, product, confidence, version)
+// 版本最长 15 个字符，优先取更具体(更长)的版本，长度相同时取较大的版本
+func betterVersion(version, current string) bool {
+	if version == "" || len(version) > 15 {
+		return false
 	}
+	if current == "" || len(version) > len(current) {
+		return true
+	}
+	return len(version) == len(current) && compareVersion(version, current) > 0
 }
 
-func (w *Wappalyzer) runMatch(text string, data string, name string, product Properties) {
-	if strings.Contains(data, text) {
-		w.setFinger(name, product, 100, "")
+// 按 . 分段比较，数字段按数值比较
+func compareVersion(a, b string) int {
+	as := strings.Split(a, ".")
+	bs := strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, aerr := strconv.Atoi(as[i])
+		bn, berr := strconv.Atoi(bs[i])
+		if aerr == nil && berr == nil {
+			if an != bn {
+				return an - bn
+			}
+			continue
+		}
+		if c := strings.Compare(as[i], bs[i]); c != 0 {
+			return c
+		}
 	}
+	return len(as) - len(bs)
 }
 
-func (w *Wappalyzer) setFinger(name string, finger Properties, confidence int, version string) {
-	categorie := make([]Categorie, 0)
-	for _, cat := range finger.Cats {
-		categorie = append(categorie, Categorie{
-			ID:   cat,
-			Name: categories[strconv.Itoa(cat)].Name,
-		})
-	}
-	w.lock.Lock()
-	w.Technologies[name] = Technologie{
-		Name:       name,
-		Confidence: confidence,
-		Version:    version,
-		Icon:       finger.ICON,
-		Website:    finger.WebSite,
-		Cpe:        finger.CPE,
-		Categories: categorie,
+// string 或 []string
+func toStrings(data interface{}) []string {
+	switch d := TypeDetect(data).(type) {
+	case string:
+		return []string{d}
+	case []string:
+		return d
 	}
-	w.lock.Unlock()
+	return nil
 }
 
-func (w *Wappalyzer) split(data interface{}, spl string) []string {
+func split(data interface{}, spl string) []string {
 	ret := make([]string, 0)
 	switch d := data.(type) {
 	case string:
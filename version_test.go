@@ -0,0 +1,68 @@
+package wappalyzer
+
+import "testing"
+
+// 规则取自 wappalyzer technologies 目录，拼接、多分组等用例在其基础上改写
+func TestPatternVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		data    string
+		exist   bool
+		version string
+	}{
+		{"Nginx", `nginx(?:/([\d.]+))?\;version:\1`, "nginx/1.8.0", true, "1.8.0"},
+		{"Nginx without version", `nginx(?:/([\d.]+))?\;version:\1`, "nginx", true, ""},
+		{"Apache", `(?:Apache(?:$|/([\d.]+)|[^/-])|(?:^|\b)HTTPD)\;version:\1`, "Apache/2.4.41 (Ubuntu)", true, "2.4.41"},
+		{"PHP", `^php/?([\d.]+)?\;version:\1`, "PHP/7.4.3", true, "7.4.3"},
+		{"PHP X-Powered-By", `php/?([\d.]+)?\;version:\1`, "php/7.4.3", true, "7.4.3"},
+		{"jQuery", `jquery[.-]([\d.]*\d)[^/]*\.js\;version:\1`, "/static/jquery-3.6.0.min.js", true, "3.6.0"},
+		{"jQuery query version", `jquery.*\.js(?:\?ver(?:sion)?=([\d.]+))?\;version:\1`, "/wp-includes/js/jquery/jquery.js?ver=1.12.4", true, "1.12.4"},
+		{"jQuery no version", `jquery.*\.js(?:\?ver(?:sion)?=([\d.]+))?\;version:\1`, "/static/jquery.js", true, ""},
+		{"jQuery without version tag", `jquery`, "/static/jquery.js", true, ""},
+		{"WordPress generator", `^WordPress(?: ([\d.]+))?\;version:\1`, "WordPress 6.1.1", true, "6.1.1"},
+		{"Drupal", `^Drupal(?:\s([\d.]+))?\;version:\1`, "Drupal 9 (https://www.drupal.org)", true, "9"},
+		{"Prototype second group", `(?:(prototype)|protoaculous)(?:-([\d.]*[\d]))?.*\.js\;version:\2`, "prototype-1.7.3.js", true, "1.7.3"},
+		{"Google Analytics ternary hit", `google-analytics\.com\/(?:ga|urchin|(analytics))\.js\;version:\1?UA:`, "https://www.google-analytics.com/analytics.js", true, "UA"},
+		{"Google Analytics ternary miss", `google-analytics\.com\/(?:ga|urchin|(analytics))\.js\;version:\1?UA:`, "https://www.google-analytics.com/ga.js", true, ""},
+		{"Google Analytics GA4", `googletagmanager\.com/gtag/js\?id=G-\;version:GA4`, "https://www.googletagmanager.com/gtag/js?id=G-XXXX", true, "GA4"},
+		{"ternary both branches", `(?:jquery-ui(\.min)?|jquery\.ui)\.js\;version:\1?1.x:2.x`, "jquery-ui.min.js", true, "1.x"},
+		{"ternary else branch", `(?:jquery-ui(\.min)?|jquery\.ui)\.js\;version:\1?1.x:2.x`, "jquery.ui.js", true, "2.x"},
+		{"concatenation", `Microsoft-IIS/(\d+)\.(\d+)\;version:\1.\2`, "Microsoft-IIS/10.0", true, "10.0"},
+		{"ternary after back-reference", `(\d+)\.(\d+)(-beta)?\;version:\1.\2\3?-beta:`, "5.2-beta", true, "5.2-beta"},
+		{"confidence and version", `nginx(?:/([\d.]+))?\;version:\1\;confidence:50`, "nginx/1.21.6", true, "1.21.6"},
+		{"Drupal lookahead", `sites\/(?!default|all).*\/files`, "/sites/example.com/files/style.css", true, ""},
+		{"Drupal lookahead excluded", `sites\/(?!default|all).*\/files`, "/sites/default/files/style.css", false, ""},
+		{"no match", `nginx(?:/([\d.]+))?\;version:\1`, "Apache", false, ""},
+	}
+	for _, test := range tests {
+		p, err := parsePattern(test.pattern)
+		if err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+		exist, version, _, _ := p.match(test.data)
+		if exist != test.exist || version != test.version {
+			t.Errorf("%s: match(%q) = %v, %q, want %v, %q", test.name, test.data, exist, version, test.exist, test.version)
+		}
+	}
+}
+
+func TestPatternConfidence(t *testing.T) {
+	tests := []struct {
+		pattern    string
+		confidence int
+	}{
+		{`nginx(?:/([\d.]+))?\;version:\1`, 100},
+		{`MySQL\;confidence:50`, 50},
+		{`nginx(?:/([\d.]+))?\;version:\1\;confidence:75`, 75},
+	}
+	for _, test := range tests {
+		p, err := parsePattern(test.pattern)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.confidence != test.confidence {
+			t.Errorf("%s: confidence = %d, want %d", test.pattern, p.confidence, test.confidence)
+		}
+	}
+}
@@ -1,148 +1,16 @@
 package wappalyzer
 
 import (
-	"embed"
-	"encoding/json"
-	"fmt"
-	"io/fs"
-	"log"
-	"strings"
+	"log/slog"
+	"sort"
 	"sync"
+	"time"
 )
 
-var schemas Schema
-var groups Groups
-var categories Categories
-var wappalyzer_fs fs.FS
 var icon_url string
 
-// 只需运行一次 - 第一个指纹wr不包含
-func InitWappalyzerDB(wr embed.FS, file_ string) error {
-	wr_sub, err := fs.Sub(wr, "wappalyzer")
-	if err != nil {
-		return err
-	}
-	wappalyzer_fs = wr_sub
-	schemas = make(Schema)
-	technologies := "src/technologies/"
-	for i := 0; i < 27; i++ {
-		var chr = string(rune(96 + i))
-		file_content := make([]byte, 0)
-		if chr == "`" {
-			file_content = []byte(file_)
-		} else {
-			file_content, err = fs.ReadFile(wr_sub, technologies+chr+".json")
-			if err != nil {
-				return err
-			}
-		}
-		var schema Schema
-		err = json.Unmarshal(file_content, &schema)
-		if err != nil {
-			return err
-		}
-		for k, v := range schema {
-			schemas[k] = v
-		}
-	}
-
-	groups = make(Groups)
-	groups_file, err := fs.ReadFile(wr_sub, "src/groups.json")
-	if err != nil {
-		return err
-	}
-	err = json.Unmarshal(groups_file, &groups)
-	if err != nil {
-		return err
-	}
-
-	categories = make(Categories)
-	categories_file, err := fs.ReadFile(wr_sub, "src/categories.json")
-	if err != nil {
-		return err
-	}
-	err = json.Unmarshal(categories_file, &categories)
-	if err != nil {
-		return err
-	}
-
-	icon_null_count := 0
-	for name, val := range schemas {
-		// 测试是否有未知类型
-		_, err = TypeTest(val.Implies)
-		if err != nil {
-			return fmt.Errorf("%s %s", name, err)
-		}
-		_, err = TypeTest(val.Requires)
-		if err != nil {
-			return fmt.Errorf("%s %s", name, err)
-		}
-		_, err = TypeTest(val.RequiresCategory)
-		if err != nil {
-			return fmt.Errorf("%s %s", name, err)
-		}
-		_, err = TypeTest(val.Excludes)
-		if err != nil {
-			return fmt.Errorf("%s %s", name, err)
-		}
-		_, err = TypeTest(val.DOM)
-		if err != nil {
-			return fmt.Errorf("%s %s", name, err)
-		}
-		_, err = TypeTest(val.DNS)
-		if err != nil {
-			return fmt.Errorf("%s %s", name, err)
-		}
-		_, err = TypeTest(val.HTML)
-		if err != nil {
-			return fmt.Errorf("%s %s", name, err)
-		}
-		_, err = TypeTest(val.TEXT)
-		if err != nil {
-			return fmt.Errorf("%s %s", name, err)
-		}
-		_, err = TypeTest(val.CSS)
-		if err != nil {
-			return fmt.Errorf("%s %s", name, err)
-		}
-		_, err = TypeTest(val.Robots)
-		if err != nil {
-			return fmt.Errorf("%s %s", name, err)
-		}
-		_, err = TypeTest(val.URL)
-		if err != nil {
-			return fmt.Errorf("%s %s", name, err)
-		}
-		_, err = TypeTest(val.XHR)
-		if err != nil {
-			return fmt.Errorf("%s %s", name, err)
-		}
-		_, err = TypeTest(val.Meta)
-		if err != nil {
-			return fmt.Errorf("%s %s", name, err)
-		}
-		_, err = TypeTest(val.ScriptSrc)
-		if err != nil {
-			return fmt.Errorf("%s %s", name, err)
-		}
-		// 测试ICON是否读取正常
-		if val.ICON != "" && !strings.Contains(val.ICON, "<") {
-			// new version
-			_, err = fs.ReadFile(wr_sub, fmt.Sprintf("src/images/icons/%s", val.ICON))
-			if err != nil {
-				// old version
-				_, err = fs.ReadFile(wr_sub, fmt.Sprintf("src/drivers/webextension/images/icons/%s", val.ICON))
-				if err != nil {
-					log.Println(err)
-				}
-			}
-			continue
-		}
-		icon_null_count++
-	}
-	log.Println(fmt.Sprintf("wappalyzer fingers count %d, groups count %d, categories count %d, no icon count %d", len(schemas), len(groups), len(categories), icon_null_count))
-	return nil
-}
+// DetectListen 同时处理的事件数
+const listenWorkers = 16
 
 /*
 // 如果需要读取ICON信息
@@ -150,7 +18,7 @@ func InitWappalyzerDB(wr embed.FS, file_ string) error {
 
 	func (a *api) GetICON(c *gin.Context) {
 		icon := c.Query("icon")
-		readICON := wappalyzer.ReadICON(icon)
+		readICON := a.db.ReadICON(icon)
 		if strings.HasSuffix(icon, "svg") {
 			c.Header("Content-Type", "image/svg+xml")
 		}
@@ -162,19 +30,64 @@ func SetReadICONURL(url string) {
 }
 
 type Wappalyzer struct {
-	Technologies map[string]Technologie
-	lock         sync.Mutex
-	displayError bool
+	technologies  map[string]Technologie // 原始检测结果，由 lock 保护
+	MinConfidence int                    // GetFingers 只返回 confidence 不低于该值的指纹
+	StyleSheets   bool                   // DetectActions 同时匹配 document.styleSheets 中的规则，样式表较多时较慢
+	Resolver      Resolver               // DetectDNS 使用的解析器，为空时使用系统配置
+	db            *DB
+	confidences   map[string]map[interface{}]int // 指纹 -> 命中的规则 -> confidence
+	lock          sync.Mutex
+	pending       sync.WaitGroup // DetectListen 中未完成的任务
+	closed        bool           // Wait 已开始，不再接收监听事件
+	workers       chan struct{}
+	Logger        *slog.Logger // 为空时使用 SetLogger 设置的日志
 }
 
 type Technologie struct {
-	Name       string      `json:"name"`       // 名称
-	Confidence int         `json:"confidence"` // 价值
-	Version    string      `json:"version"`    // 版本
-	Icon       string      `json:"icon"`       // 产品标识
-	Website    string      `json:"website"`    // 产品网站
-	Cpe        string      `json:"cpe"`        // CPE
-	Categories []Categorie `json:"categories"` // 产品分类
+	Name       string      `json:"name"`                 // 名称
+	Confidence int         `json:"confidence"`           // 价值
+	Version    string      `json:"version"`              // 版本
+	Icon       string      `json:"icon"`                 // 产品标识
+	Website    string      `json:"website"`              // 产品网站
+	Cpe        string      `json:"cpe"`                  // CPE
+	Categories []Categorie `json:"categories"`           // 产品分类
+	ImpliedBy  []string    `json:"implied_by,omitempty"` // 由哪些指纹推导而来
+	Evidence   []Evidence  `json:"evidence"`             // 检测依据
+}
+
+// 检测结果快照
+type Result struct {
+	Time          time.Time     `json:"time"`           // 生成时间
+	MinConfidence int           `json:"min_confidence"` // 生成时的 MinConfidence
+	Technologies  []Technologie `json:"technologies"`   // 按名称排序
+}
+
+// 按名称查找指纹
+func (r Result) Get(name string) (Technologie, bool) {
+	i := sort.Search(len(r.Technologies), func(i int) bool {
+		return r.Technologies[i].Name >= name
+	})
+	if i < len(r.Technologies) && r.Technologies[i].Name == name {
+		return r.Technologies[i], true
+	}
+	return Technologie{}, false
+}
+
+// 检测依据
+type Evidence struct {
+	Source   string `json:"source"`   // 来源 headers、cookies、dom、js、meta、scriptSrc、css、dns、robots、url、xhr、certIssuer、implied 等
+	Pattern  string `json:"pattern"`  // 命中的规则
+	Match    string `json:"match"`    // 匹配到的内容，过长时截断
+	Location string `json:"location"` // 输入位置，如 header 名、cookie 名、selector、script URL
+
+	Certificate *Certificate `json:"certificate,omitempty"` // certIssuer 命中时的证书信息
+}
+
+// TLS 证书
+type Certificate struct {
+	Subject string   `json:"subject"` // CN
+	SAN     []string `json:"san"`
+	Issuer  string   `json:"issuer"` // O 与 CN，如 "Let's Encrypt, R3"
 }
 
 type Categorie struct {
@@ -182,63 +95,36 @@ type Categorie struct {
 	Name string `json:"name"`
 }
 
-func NewWappalyzer(displayError bool) *Wappalyzer {
+func NewWappalyzer(db *DB) *Wappalyzer {
 	ts := make(map[string]Technologie)
-	return &Wappalyzer{Technologies: ts, displayError: displayError}
+	return &Wappalyzer{technologies: ts, db: db, confidences: make(map[string]map[interface{}]int), workers: make(chan struct{}, listenWorkers)}
 }
 
-func (w *Wappalyzer) GetFingers() map[string]Technologie {
-	for name := range w.Technologies {
-		if schemas[name].Excludes == nil {
-			continue
-		}
-		switch exc := TypeDetect(schemas[name].Excludes).(type) {
-		case string:
-			delete(w.Technologies, exc)
-			break
-		case []string:
-			for i := 0; i < len(exc); i++ {
-				delete(w.Technologies, exc[i])
-			}
-			break
-		default:
-			fmt.Println("found no excludes type", schemas[name].Excludes)
-		}
-	}
-	for name := range w.Technologies {
-		if schemas[name].Implies == nil {
-			continue
-		}
-		switch exc := TypeDetect(schemas[name].Implies).(type) {
-		case string:
-			w.setFinger(name, schemas[name], 100, "")
-			break
-		case []string:
-			for i := 0; i < len(exc); i++ {
-				w.setFinger(name, schemas[exc[i]], 100, "")
-			}
-			break
-		default:
-			fmt.Println("found no implies type", schemas[name].Excludes)
-		}
-	}
-	for name, value := range w.Technologies {
-		if value.Icon == "" {
-			continue
-		}
-		value.Icon = icon_url + value.Icon
-		w.Technologies[name] = value
-	}
-	return w.Technologies
+// 返回未经 excludes、implies、requires 处理的原始检测结果副本
+func (w *Wappalyzer) Detected() map[string]Technologie {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return cloneTechnologies(w.technologies)
 }
 
-func ReadICON(filename string) string {
-	icon, err := fs.ReadFile(wappalyzer_fs, "src/images/icons/"+filename)
-	if err != nil {
-		icon, err = fs.ReadFile(wappalyzer_fs, "src/drivers/webextension/images/icons/"+filename)
-		if err != nil {
-			return ""
+// 返回当前检测结果的快照，处理 excludes、implies、requires 并过滤低于 MinConfidence 的指纹
+// 不修改原始检测结果，可重复、并发调用
+func (w *Wappalyzer) GetFingers() Result {
+	w.lock.Lock()
+	detected := cloneTechnologies(w.technologies)
+	min_confidence := w.MinConfidence
+	w.lock.Unlock()
+
+	techs := w.resolve(detected, min_confidence)
+	result := Result{Time: time.Now(), MinConfidence: min_confidence, Technologies: make([]Technologie, 0, len(techs))}
+	for _, value := range techs {
+		if value.Icon != "" {
+			value.Icon = icon_url + value.Icon
 		}
+		result.Technologies = append(result.Technologies, value)
 	}
-	return string(icon)
+	sort.Slice(result.Technologies, func(i, j int) bool {
+		return result.Technologies[i].Name < result.Technologies[j].Name
+	})
+	return result
 }